@@ -0,0 +1,68 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"payment-service/clients/config"
+	"payment-service/common/util"
+	config2 "payment-service/config"
+	"payment-service/constants"
+	"time"
+)
+
+const callbackTimeout = 10 * time.Second
+
+type CallbackClient struct {
+	client config.IClientConfig
+}
+
+type ICallbackClient interface {
+	Send(context.Context, string, string, []byte) (int, error)
+}
+
+func NewCallbackClient(client config.IClientConfig) ICallbackClient {
+	return &CallbackClient{
+		client: client,
+	}
+}
+
+// Send POSTs the payload to the callback URL. The x-api-key is built the same
+// way as for our internal services, with the registration secret as the
+// signature key, and x-signature is an HMAC of the request time and body so
+// receivers can check the payload was not altered.
+func (c *CallbackClient) Send(ctx context.Context, url, secret string, payload []byte) (int, error) {
+	unixTime := time.Now().Unix()
+	requestAt := fmt.Sprintf("%d", unixTime)
+	apiKey := util.GenerateSHA256(fmt.Sprintf("%s:%s:%s",
+		config2.Config.AppName,
+		secret,
+		requestAt,
+	))
+	signature := util.GenerateHMACSHA256(secret, fmt.Sprintf("%s.%s", requestAt, payload))
+
+	request := c.client.Client().Clone().
+		Timeout(callbackTimeout).
+		Post(url).
+		Set(constants.XApiKey, apiKey).
+		Set(constants.XServiceName, config2.Config.AppName).
+		Set(constants.XRequestAt, requestAt).
+		Set(constants.XSignature, signature)
+
+	requestID, ok := ctx.Value(constants.RequestID).(string)
+	if ok && requestID != "" {
+		request = request.Set(constants.XRequestID, requestID)
+	}
+
+	// Send the payload verbatim; re-encoding it would break the signature.
+	request.BounceToRawString = true
+	resps, body, errs := request.SendString(string(payload)).End()
+	if len(errs) > 0 {
+		return 0, errs[0]
+	}
+
+	if resps.StatusCode < 200 || resps.StatusCode >= 300 {
+		return resps.StatusCode, fmt.Errorf("callback response %d: %s", resps.StatusCode, body)
+	}
+
+	return resps.StatusCode, nil
+}
@@ -0,0 +1,44 @@
+package clients
+
+import (
+	"net/http"
+	"payment-service/domain/dto"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+)
+
+// GatewayPayment is the payment created at the gateway for an order: a
+// payment page, or for a direct charge the details the customer pays with.
+type GatewayPayment struct {
+	Token         string
+	PaymentLink   string
+	TransactionID string
+	PaymentType   string
+	Bank          string
+	VANumber      string
+	BillerCode    string
+	QRString      string
+}
+
+type GatewayTransaction struct {
+	TransactionID     string
+	TransactionStatus string
+}
+
+type GatewayRefund struct {
+	RefundKey string
+	Amount    string
+	Status    string
+}
+
+// IPaymentGateway is what the payment service needs from a payment provider.
+// Statuses and notifications are mapped onto dto.Webhook, so every provider
+// goes through the same state machine as Midtrans.
+type IPaymentGateway interface {
+	CreatePayment(*dto.PaymentRequest) (*GatewayPayment, error)
+	GetStatus(string) (*dto.Webhook, error)
+	Cancel(string) (*GatewayTransaction, error)
+	Expire(string) (*GatewayTransaction, error)
+	Refund(string, string, money.Money, string) (*GatewayRefund, error)
+	ParseNotification([]byte, http.Header) (*dto.Webhook, error)
+}
@@ -0,0 +1,140 @@
+package clients
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	clients "payment-service/clients/midtrans"
+	"payment-service/common/util"
+	"payment-service/constants"
+	errPayment "payment-service/constants/error/payment"
+	"payment-service/domain/dto"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/sirupsen/logrus"
+)
+
+type MidtransGateway struct {
+	client    clients.IMidtransClient
+	serverKey string
+}
+
+func NewMidtransGateway(client clients.IMidtransClient, serverKey string) IPaymentGateway {
+	return &MidtransGateway{
+		client:    client,
+		serverKey: serverKey,
+	}
+}
+
+func (m *MidtransGateway) CreatePayment(req *dto.PaymentRequest) (*GatewayPayment, error) {
+	if req.Mode == constants.PaymentModeCore {
+		return m.charge(req)
+	}
+
+	response, err := m.client.CreatePaymentLink(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayPayment{
+		Token:       response.Token,
+		PaymentLink: response.RedirectURL,
+	}, nil
+}
+
+// charge maps a Core API charge; the bill key of an e-channel payment is
+// stored as its VA number, like the notifications do.
+func (m *MidtransGateway) charge(req *dto.PaymentRequest) (*GatewayPayment, error) {
+	response, err := m.client.Charge(req)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &GatewayPayment{
+		PaymentLink:   response.QRCodeURL,
+		TransactionID: response.TransactionID,
+		PaymentType:   response.PaymentType,
+		Bank:          response.Bank,
+		VANumber:      response.VANumber,
+		BillerCode:    response.BillerCode,
+		QRString:      response.QRString,
+	}
+
+	if response.PaymentType == constants.Echannel {
+		payment.Bank = constants.BankMandiri
+		payment.VANumber = response.BillKey
+	}
+
+	return payment, nil
+}
+
+func (m *MidtransGateway) GetStatus(orderID string) (*dto.Webhook, error) {
+	return m.client.GetStatus(orderID)
+}
+
+func (m *MidtransGateway) Cancel(orderID string) (*GatewayTransaction, error) {
+	return m.transaction(m.client.Cancel(orderID))
+}
+
+func (m *MidtransGateway) Expire(orderID string) (*GatewayTransaction, error) {
+	return m.transaction(m.client.Expire(orderID))
+}
+
+func (m *MidtransGateway) transaction(
+	response *clients.MidtransTransactionData,
+	err error,
+) (*GatewayTransaction, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayTransaction{
+		TransactionID:     response.TransactionID,
+		TransactionStatus: response.TransactionStatus,
+	}, nil
+}
+
+func (m *MidtransGateway) Refund(
+	orderID string,
+	refundKey string,
+	amount money.Money,
+	reason string,
+) (*GatewayRefund, error) {
+	response, err := m.client.Refund(orderID, refundKey, amount, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayRefund{
+		RefundKey: response.RefundKey,
+		Amount:    response.RefundAmount,
+		Status:    response.TransactionStatus,
+	}, nil
+}
+
+// ParseNotification decodes the HTTP notification and checks its
+// signature_key, a SHA512 of the order id, status code, gross amount and our
+// server key.
+func (m *MidtransGateway) ParseNotification(body []byte, _ http.Header) (*dto.Webhook, error) {
+	var req dto.Webhook
+	err := json.Unmarshal(body, &req)
+	if err != nil {
+		logrus.Warnf("rejected midtrans webhook: %v", err)
+		return nil, errPayment.ErrInvalidPayload
+	}
+	req.RawPayload = body
+
+	signature := util.GenerateSHA512(fmt.Sprintf("%s%s%s%s",
+		req.OrderID.String(),
+		req.StatusCode,
+		req.GrossAmount,
+		m.serverKey,
+	))
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(req.SignatureKey)) != 1 {
+		logrus.Warnf("rejected webhook for order %s: signature mismatch", req.OrderID.String())
+		return nil, errPayment.ErrInvalidSignature
+	}
+
+	return &req, nil
+}
@@ -0,0 +1,116 @@
+package clients
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	errPayment "payment-service/constants/error/payment"
+	"testing"
+)
+
+// testServerKey signed the sample notifications under testdata/midtrans.
+const testServerKey = "SB-Mid-server-payment-service-test"
+
+func readNotification(t *testing.T, name string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", "midtrans", name+".json"))
+	if err != nil {
+		t.Fatalf("read sample %s: %v", name, err)
+	}
+
+	return body
+}
+
+func TestMidtransGatewayParseNotification(t *testing.T) {
+	samples := []string{
+		"settlement-bank-transfer",
+		"pending-echannel",
+		"expire-gopay",
+		"capture-credit-card",
+	}
+
+	tests := []struct {
+		name      string
+		serverKey string
+		tamper    func([]byte) []byte
+		wantErr   error
+	}{
+		{
+			name:      "valid signature",
+			serverKey: testServerKey,
+		},
+		{
+			name:      "other server key",
+			serverKey: "SB-Mid-server-other",
+			wantErr:   errPayment.ErrInvalidSignature,
+		},
+		{
+			name:      "empty server key",
+			serverKey: "",
+			wantErr:   errPayment.ErrInvalidSignature,
+		},
+		{
+			name:      "tampered gross amount",
+			serverKey: testServerKey,
+			tamper: func(body []byte) []byte {
+				return bytes.Replace(body, []byte(`"gross_amount": "`), []byte(`"gross_amount": "1`), 1)
+			},
+			wantErr: errPayment.ErrInvalidSignature,
+		},
+		{
+			name:      "tampered status code",
+			serverKey: testServerKey,
+			tamper: func(body []byte) []byte {
+				return bytes.Replace(body, []byte(`"status_code": "2`), []byte(`"status_code": "4`), 1)
+			},
+			wantErr: errPayment.ErrInvalidSignature,
+		},
+		{
+			name:      "missing signature",
+			serverKey: testServerKey,
+			tamper: func(body []byte) []byte {
+				return bytes.Replace(body, []byte(`"signature_key"`), []byte(`"signature"`), 1)
+			},
+			wantErr: errPayment.ErrInvalidSignature,
+		},
+		{
+			name:      "truncated body",
+			serverKey: testServerKey,
+			tamper: func(body []byte) []byte {
+				return body[:len(body)/2]
+			},
+			wantErr: errPayment.ErrInvalidPayload,
+		},
+	}
+
+	for _, sample := range samples {
+		for _, tt := range tests {
+			t.Run(sample+"/"+tt.name, func(t *testing.T) {
+				body := readNotification(t, sample)
+				if tt.tamper != nil {
+					body = tt.tamper(body)
+				}
+
+				gateway := NewMidtransGateway(nil, tt.serverKey)
+				webhook, err := gateway.ParseNotification(body, nil)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseNotification() error = %v, want %v", err, tt.wantErr)
+				}
+
+				if tt.wantErr != nil {
+					return
+				}
+
+				if webhook.TransactionStatus == "" || webhook.GrossAmount == "" {
+					t.Errorf("ParseNotification() = %+v, want the decoded notification", webhook)
+				}
+
+				if !bytes.Equal(webhook.RawPayload, body) {
+					t.Errorf("ParseNotification() did not keep the raw payload")
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package clients
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	clients "payment-service/clients/midtrans"
+	"payment-service/constants"
+	"payment-service/domain/dto"
+	"payment-service/internal/midtranssim"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/google/uuid"
+)
+
+// TestMidtransGatewayAgainstSimulator creates a Snap payment on the
+// simulator, pays it, checks the webhook receives a notification the gateway
+// accepts and that the status endpoint agrees with it.
+func TestMidtransGatewayAgainstSimulator(t *testing.T) {
+	notifications := make(chan *dto.Webhook, 1)
+	var gateway IPaymentGateway
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		notification, err := gateway.ParseNotification(body, r.Header)
+		if err != nil {
+			t.Errorf("ParseNotification() error = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		notifications <- notification
+	}))
+	defer webhook.Close()
+
+	simulator := midtranssim.New(testServerKey, webhook.URL)
+	midtrans := httptest.NewServer(simulator)
+	defer midtrans.Close()
+
+	gateway = NewMidtransGateway(clients.NewMidtransClient(testServerKey, false, midtrans.URL, nil), testServerKey)
+
+	orderID := uuid.New()
+	payment, err := gateway.CreatePayment(&dto.PaymentRequest{
+		OrderID:   orderID.String(),
+		Amount:    money.FromMajor(150000, money.IDR),
+		ExpiredAt: time.Now().Add(time.Hour),
+		Gateway:   constants.GatewayMidtrans,
+	})
+	if err != nil {
+		t.Fatalf("CreatePayment() error = %v", err)
+	}
+
+	if payment.Token == "" || !strings.HasPrefix(payment.PaymentLink, midtrans.URL) {
+		t.Fatalf("CreatePayment() = %+v, want a token and a link to the simulator", payment)
+	}
+
+	webhookStatus, err := simulator.Notify(orderID.String(), midtranssim.StatusSettlement, "bank_transfer")
+	if err != nil || webhookStatus != http.StatusOK {
+		t.Fatalf("Notify() = %d, %v, want %d", webhookStatus, err, http.StatusOK)
+	}
+
+	notification := <-notifications
+	if notification.OrderID != orderID ||
+		notification.TransactionStatus != constants.SettlementString ||
+		notification.GrossAmount != "150000.00" {
+		t.Errorf("notification = %+v, want a settlement of 150000.00 for order %s", notification, orderID)
+	}
+
+	status, err := gateway.GetStatus(orderID.String())
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+
+	if status.TransactionStatus != constants.SettlementString ||
+		status.TransactionID != notification.TransactionID {
+		t.Errorf("GetStatus() = %+v, want the settled transaction %s", status, notification.TransactionID)
+	}
+}
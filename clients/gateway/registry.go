@@ -0,0 +1,45 @@
+package clients
+
+import (
+	clientsMidtrans "payment-service/clients/midtrans"
+	clientsXendit "payment-service/clients/xendit"
+	"payment-service/constants"
+	errPayment "payment-service/constants/error/payment"
+)
+
+type GatewayRegistry struct {
+	gateways map[constants.PaymentGateway]IPaymentGateway
+}
+
+type IGatewayRegistry interface {
+	Get(constants.PaymentGateway) (IPaymentGateway, error)
+}
+
+func NewGatewayRegistry(
+	midtrans clientsMidtrans.IMidtransClient,
+	midtransServerKey string,
+	xendit clientsXendit.IXenditClient,
+	xenditCallbackToken string,
+) IGatewayRegistry {
+	return &GatewayRegistry{
+		gateways: map[constants.PaymentGateway]IPaymentGateway{
+			constants.GatewayMidtrans: NewMidtransGateway(midtrans, midtransServerKey),
+			constants.GatewayXendit:   NewXenditGateway(xendit, xenditCallbackToken),
+		},
+	}
+}
+
+// Get returns the gateway by name. Payments created before gateways were
+// configurable have no name and belong to Midtrans.
+func (g *GatewayRegistry) Get(name constants.PaymentGateway) (IPaymentGateway, error) {
+	if name == "" {
+		name = constants.GatewayMidtrans
+	}
+
+	gateway, ok := g.gateways[name]
+	if !ok {
+		return nil, errPayment.ErrUnknownGateway
+	}
+
+	return gateway, nil
+}
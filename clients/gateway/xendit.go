@@ -0,0 +1,208 @@
+package clients
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	clients "payment-service/clients/xendit"
+	"payment-service/constants"
+	errPayment "payment-service/constants/error/payment"
+	"payment-service/domain/dto"
+	"strings"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const xenditInvoicePending = "PENDING"
+
+// xenditStatuses maps the invoice statuses onto the Midtrans transaction
+// statuses the payment state machine understands.
+var xenditStatuses = map[string]constants.PaymentStatusString{
+	xenditInvoicePending: constants.PendingString,
+	"PAID":               constants.SettlementString,
+	"SETTLED":            constants.SettlementString,
+	"EXPIRED":            constants.ExpiredString,
+}
+
+// xenditPaymentTypes maps the invoice payment methods onto the Midtrans
+// payment types, so the payment method columns are filled the same way.
+var xenditPaymentTypes = map[string]string{
+	"BANK_TRANSFER":            constants.BankTransfer,
+	"CALLBACK_VIRTUAL_ACCOUNT": constants.BankTransfer,
+	"CREDIT_CARD":              constants.CreditCard,
+	"EWALLET":                  constants.EWallet,
+	"QR_CODE":                  constants.Qris,
+	"RETAIL_OUTLET":            constants.Cstore,
+}
+
+type XenditGateway struct {
+	client        clients.IXenditClient
+	callbackToken string
+}
+
+func NewXenditGateway(client clients.IXenditClient, callbackToken string) IPaymentGateway {
+	return &XenditGateway{
+		client:        client,
+		callbackToken: callbackToken,
+	}
+}
+
+func (x *XenditGateway) CreatePayment(req *dto.PaymentRequest) (*GatewayPayment, error) {
+	if req.Mode == constants.PaymentModeCore {
+		return nil, errPayment.ErrUnsupportedMode
+	}
+
+	if len(req.EnabledPayments) > 0 || len(req.BankPreferences) > 0 || len(req.PaymentOptions) > 0 {
+		return nil, errPayment.ErrUnsupportedOption
+	}
+
+	invoice, err := x.client.CreateInvoice(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayPayment{
+		Token:       invoice.ID,
+		PaymentLink: invoice.InvoiceURL,
+	}, nil
+}
+
+func (x *XenditGateway) GetStatus(orderID string) (*dto.Webhook, error) {
+	invoice, err := x.client.GetInvoiceByExternalID(orderID)
+	if err != nil || invoice == nil {
+		return nil, err
+	}
+
+	payload, _ := json.Marshal(invoice)
+	return x.toWebhook(invoice, payload)
+}
+
+func (x *XenditGateway) Cancel(orderID string) (*GatewayTransaction, error) {
+	return x.Expire(orderID)
+}
+
+// Expire expires the pending invoice of the order. Xendit has no cancel for
+// invoices, so cancelling expires it too.
+func (x *XenditGateway) Expire(orderID string) (*GatewayTransaction, error) {
+	invoice, err := x.client.GetInvoiceByExternalID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invoice == nil {
+		logrus.Infof("invoice %s not found in xendit", orderID)
+		return &GatewayTransaction{}, nil
+	}
+
+	if invoice.Status == xenditInvoicePending {
+		invoice, err = x.client.ExpireInvoice(invoice.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &GatewayTransaction{
+		TransactionID:     invoice.ID,
+		TransactionStatus: string(xenditStatuses[invoice.Status]),
+	}, nil
+}
+
+func (x *XenditGateway) Refund(
+	orderID string,
+	refundKey string,
+	amount money.Money,
+	reason string,
+) (*GatewayRefund, error) {
+	invoice, err := x.client.GetInvoiceByExternalID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invoice == nil {
+		logrus.Errorf("Error refund transaction: invoice %s not found in xendit", orderID)
+		return nil, errPayment.ErrRefundFailed
+	}
+
+	refund, err := x.client.Refund(invoice.ID, refundKey, amount, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayRefund{
+		RefundKey: refund.ReferenceID,
+		Amount:    refund.Amount.Decimal(),
+		Status:    refund.Status,
+	}, nil
+}
+
+// ParseNotification decodes an invoice callback. Xendit does not sign the
+// body, it sends the callback token of the account in x-callback-token.
+func (x *XenditGateway) ParseNotification(body []byte, header http.Header) (*dto.Webhook, error) {
+	token := header.Get(constants.XCallbackToken)
+	if x.callbackToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(x.callbackToken)) != 1 {
+		logrus.Warnf("rejected xendit webhook: callback token mismatch")
+		return nil, errPayment.ErrInvalidSignature
+	}
+
+	var invoice clients.XenditInvoice
+	err := json.Unmarshal(body, &invoice)
+	if err != nil {
+		logrus.Warnf("rejected xendit webhook: %v", err)
+		return nil, errPayment.ErrInvalidPayload
+	}
+
+	return x.toWebhook(&invoice, body)
+}
+
+func (x *XenditGateway) toWebhook(invoice *clients.XenditInvoice, payload []byte) (*dto.Webhook, error) {
+	orderID, err := uuid.Parse(invoice.ExternalID)
+	if err != nil {
+		logrus.Warnf("rejected xendit webhook: invalid external id %s", invoice.ExternalID)
+		return nil, errPayment.ErrInvalidPayload
+	}
+
+	status, ok := xenditStatuses[invoice.Status]
+	if !ok {
+		status = constants.PaymentStatusString(strings.ToLower(invoice.Status))
+	}
+
+	webhook := &dto.Webhook{
+		OrderID:           orderID,
+		TransactionID:     invoice.ID,
+		TransactionStatus: status,
+		TransactionTime:   invoice.Created,
+		SettlementTime:    invoice.PaidAt,
+		GrossAmount:       invoice.Amount.Decimal(),
+		Currency:          invoice.Currency,
+		PaymentType:       xenditPaymentTypes[invoice.PaymentMethod],
+		RawPayload:        payload,
+	}
+
+	if invoice.PaidAmount.Amount > 0 {
+		paidAmount := invoice.PaidAmount.Decimal()
+		webhook.PaymentAmount = []dto.PaymentAmount{{
+			PaidAt: &invoice.PaidAt,
+			Amount: &paidAmount,
+		}}
+	}
+
+	channel := strings.ToLower(invoice.PaymentChannel)
+	switch webhook.PaymentType {
+	case constants.BankTransfer:
+		webhook.VANumbers = []dto.VANumber{{
+			VaNumber: invoice.PaymentDestination,
+			Bank:     strings.ToLower(invoice.BankCode),
+		}}
+	case constants.EWallet, constants.Qris:
+		webhook.Issuer = &channel
+	case constants.Cstore:
+		webhook.Store = channel
+		webhook.PaymentCode = invoice.PaymentDestination
+	case constants.CreditCard:
+		webhook.Bank = strings.ToLower(invoice.BankCode)
+	}
+
+	return webhook, nil
+}
@@ -1,11 +1,17 @@
 package clients
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"payment-service/constants"
 	errConstants "payment-service/constants/error/payment"
 	"payment-service/domain/dto"
 	"time"
 
+	"github.com/FaisalABR/payment-service/pkg/money"
 	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
 	"github.com/midtrans/midtrans-go/snap"
 	"github.com/sirupsen/logrus"
 )
@@ -13,31 +19,137 @@ import (
 type MidtransClient struct {
 	ServerKey    string
 	IsProduction bool
+	BaseURL      string
+	// EnabledPayments is the allow-list of payment methods, empty allows all.
+	EnabledPayments []string
 }
 
 type IMidtransClient interface {
 	CreatePaymentLink(*dto.PaymentRequest) (*MidtransData, error)
+	Charge(*dto.PaymentRequest) (*MidtransChargeData, error)
+	Refund(string, string, money.Money, string) (*MidtransRefundData, error)
+	Cancel(string) (*MidtransTransactionData, error)
+	Expire(string) (*MidtransTransactionData, error)
+	GetStatus(string) (*dto.Webhook, error)
 }
 
-func NewMidtransClient(serverKey string, isProduction bool) *MidtransClient {
+// NewMidtransClient creates the client. An empty baseURL keeps the URLs of
+// the SDK, any other value sends both the Snap and Core API requests there,
+// e.g. to the simulator in internal/midtranssim.
+func NewMidtransClient(
+	serverKey string,
+	isProduction bool,
+	baseURL string,
+	enabledPayments []string,
+) *MidtransClient {
 	return &MidtransClient{
-		ServerKey:    serverKey,
-		IsProduction: isProduction,
+		ServerKey:       serverKey,
+		IsProduction:    isProduction,
+		BaseURL:         baseURL,
+		EnabledPayments: enabledPayments,
 	}
 }
 
-func (m *MidtransClient) CreatePaymentLink(request *dto.PaymentRequest) (*MidtransData, error) {
-	var (
-		snapClient   snap.Client
-		isProduction = midtrans.Sandbox
-	)
+func (m *MidtransClient) environment() midtrans.EnvironmentType {
+	if m.IsProduction {
+		return midtrans.Production
+	}
+
+	return midtrans.Sandbox
+}
+
+// httpClient returns the HTTP client of the SDK, rewritten to the base URL
+// when one is configured.
+func (m *MidtransClient) httpClient() midtrans.HttpClient {
+	httpClient := midtrans.GetHttpClient(m.environment())
+	if m.BaseURL == "" {
+		return httpClient
+	}
+
+	baseURL, err := url.Parse(m.BaseURL)
+	if err != nil {
+		logrus.Errorf("invalid midtrans base url %s: %v", m.BaseURL, err)
+		return httpClient
+	}
+
+	httpClient.HttpClient = &http.Client{
+		Timeout: midtrans.DefaultHttpTimeout,
+		Transport: &baseURLTransport{
+			baseURL: baseURL,
+			next:    http.DefaultTransport,
+		},
+	}
+	return httpClient
+}
+
+func (m *MidtransClient) coreClient() coreapi.Client {
+	var coreClient coreapi.Client
+	coreClient.New(m.ServerKey, m.environment())
+	coreClient.HttpClient = m.httpClient()
+	return coreClient
+}
 
-	expiryDateTime := request.ExpiredAt
+func (m *MidtransClient) customerDetail(customer *dto.CustomerDetail) *midtrans.CustomerDetails {
+	if customer == nil {
+		return nil
+	}
+
+	return &midtrans.CustomerDetails{
+		FName: customer.Name,
+		Email: customer.Email,
+		Phone: customer.Phone,
+	}
+}
+
+// itemDetails sends every line item, adjustments included. Midtrans only
+// takes whole rupiah and rejects a transaction whose rounded items don't add
+// up to the gross amount, so that is checked here before calling it.
+// Requests without items send none.
+func (m *MidtransClient) itemDetails(request *dto.PaymentRequest) (*[]midtrans.ItemDetails, error) {
+	lineItems := request.LineItems()
+	if len(lineItems) == 0 {
+		return nil, nil
+	}
+
+	total := money.New(0, request.Amount.Currency)
+	items := make([]midtrans.ItemDetails, 0, len(lineItems))
+	for _, item := range lineItems {
+		subtotal, err := item.Amount.Round().Mul(int64(item.Quantity))
+		if err != nil {
+			logrus.Errorf("item %s of order %s: %v", item.ID, request.OrderID, err)
+			return nil, errConstants.ErrItemTotalMismatch
+		}
+
+		total, err = total.Add(subtotal)
+		if err != nil {
+			logrus.Errorf("item total of order %s: %v", request.OrderID, err)
+			return nil, errConstants.ErrItemTotalMismatch
+		}
+
+		items = append(items, midtrans.ItemDetails{
+			ID:    item.ID,
+			Price: item.Amount.Major(),
+			Qty:   int32(item.Quantity),
+			Name:  item.Name,
+		})
+	}
+
+	if total.Major() != request.Amount.Major() {
+		logrus.Errorf("item total %s does not match amount %s for order %s",
+			total, request.Amount, request.OrderID)
+		return nil, errConstants.ErrItemTotalMismatch
+	}
+
+	return &items, nil
+}
+
+// expiry converts an expiry time into the duration and unit Midtrans expects.
+func (m *MidtransClient) expiry(expiryDateTime time.Time) (int64, string, error) {
 	currentTime := time.Now()
 	duration := expiryDateTime.Sub(currentTime)
 	if duration <= 0 {
 		logrus.Errorf("ExpiredAt is invalid")
-		return nil, errConstants.ErrExpiredAt
+		return 0, "", errConstants.ErrExpiredAt
 	}
 
 	expiryUnit := "minute"
@@ -50,50 +162,304 @@ func (m *MidtransClient) CreatePaymentLink(request *dto.PaymentRequest) (*Midtra
 		expiryDuration = int64(duration.Hours() / 24)
 	}
 
-	if m.IsProduction {
-		isProduction = midtrans.Production
+	return expiryDuration, expiryUnit, nil
+}
+
+// grossAmount is the amount in whole rupiah, Midtrans can't charge sen.
+func (m *MidtransClient) grossAmount(amount money.Money) (int64, error) {
+	if amount.Round() != amount {
+		logrus.Errorf("amount %s is not in whole rupiah", amount)
+		return 0, errConstants.ErrFractionalAmount
 	}
 
+	return amount.Major(), nil
+}
+
+func (m *MidtransClient) CreatePaymentLink(request *dto.PaymentRequest) (*MidtransData, error) {
+	var (
+		snapClient   snap.Client
+		isProduction = m.environment()
+	)
+
 	if isProduction == midtrans.Production {
 		logrus.Info("Running in Production mode")
 	} else {
 		logrus.Info("Running in Sandbox mode")
 	}
 
+	grossAmount, err := m.grossAmount(request.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := m.itemDetails(request)
+	if err != nil {
+		return nil, err
+	}
+
+	enabledPayments, err := m.enabledPayments(request)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := m.paymentOptions(request)
+	if err != nil {
+		return nil, err
+	}
+
 	snapClient.New(m.ServerKey, isProduction)
+	snapClient.HttpClient = m.httpClient()
 	req := &snap.Request{
 		TransactionDetails: midtrans.TransactionDetails{
 			OrderID:  request.OrderID,
-			GrossAmt: int64(request.Amount),
-		},
-		CustomerDetail: &midtrans.CustomerDetails{
-			FName: request.CustomerDetail.Name,
-			Email: request.CustomerDetail.Email,
-			Phone: request.CustomerDetail.Phone,
-		},
-		Items: &[]midtrans.ItemDetails{
-			{
-				ID:    request.ItemDetails[0].ID,
-				Price: int64(request.ItemDetails[0].Amount),
-				Qty:   int32(request.ItemDetails[0].Quantity),
-				Name:  request.ItemDetails[0].Name,
-			},
-		},
-		Expiry: &snap.ExpiryDetails{
-			Duration: expiryDuration,
-			Unit:     expiryUnit,
+			GrossAmt: grossAmount,
 		},
+		CustomerDetail:  m.customerDetail(request.CustomerDetail),
+		Items:           items,
+		EnabledPayments: enabledPayments,
+	}
+
+	expiredAt, err := m.applySnapOptions(req, options, request.ExpiredAt)
+	if err != nil {
+		return nil, err
 	}
 
-	response, err := snapClient.CreateTransaction(req)
+	expiryDuration, expiryUnit, err := m.expiry(expiredAt)
 	if err != nil {
-		logrus.Errorf("Error create transaction: %v", err)
 		return nil, err
 	}
 
+	req.Expiry = &snap.ExpiryDetails{
+		Duration: expiryDuration,
+		Unit:     expiryUnit,
+	}
+
+	// The SDK returns a *midtrans.Error, keep it out of err so a nil pointer
+	// does not become a non-nil error.
+	response, snapErr := snapClient.CreateTransaction(req)
+	if snapErr != nil {
+		logrus.Errorf("Error create transaction: %v", snapErr)
+		return nil, snapErr
+	}
+
 	return &MidtransData{
 		Token:       response.Token,
 		RedirectURL: response.RedirectURL,
 	}, nil
 
 }
+
+// Charge creates the transaction through the Core API, which returns the VA
+// number, bill key or QR string right away instead of a Snap page. Without a
+// bank the first preferred bank is charged.
+func (m *MidtransClient) Charge(request *dto.PaymentRequest) (*MidtransChargeData, error) {
+	bank := request.Bank
+	if bank == "" && len(request.BankPreferences) > 0 {
+		bank = request.BankPreferences[0]
+	}
+
+	method := chargeMethod(request.PaymentType, bank)
+	if !m.allowed(method) {
+		logrus.Errorf("payment method %s is not enabled for order %s", method, request.OrderID)
+		return nil, errConstants.ErrMethodNotAllowed
+	}
+
+	options, err := m.paymentOptions(request)
+	if err != nil {
+		return nil, err
+	}
+
+	option := options[method]
+	expiredAt := request.ExpiredAt
+	if option.ExpiredAt != nil {
+		expiredAt = *option.ExpiredAt
+	}
+
+	expiryDuration, expiryUnit, err := m.expiry(expiredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	grossAmount, err := m.grossAmount(request.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := m.itemDetails(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &coreapi.ChargeReq{
+		PaymentType: coreapi.CoreapiPaymentType(request.PaymentType),
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  request.OrderID,
+			GrossAmt: grossAmount,
+		},
+		CustomerDetails: m.customerDetail(request.CustomerDetail),
+		Items:           items,
+		CustomExpiry: &coreapi.CustomExpiry{
+			ExpiryDuration: int(expiryDuration),
+			Unit:           expiryUnit,
+		},
+	}
+
+	switch request.PaymentType {
+	case constants.BankTransfer:
+		req.BankTransfer = &coreapi.BankTransferDetails{
+			Bank:     midtrans.Bank(bank),
+			VaNumber: option.VANumber,
+		}
+	case constants.Echannel:
+		req.EChannel = &coreapi.EChannelDetail{
+			BillInfo1: "Order:",
+			BillInfo2: request.OrderID,
+		}
+	case constants.Qris:
+		req.Qris = &coreapi.QrisDetails{}
+	}
+
+	coreClient := m.coreClient()
+	response, chargeErr := coreClient.ChargeTransaction(req)
+	if chargeErr != nil {
+		logrus.Errorf("Error charge transaction: %v", chargeErr)
+		return nil, chargeErr
+	}
+
+	charge := &MidtransChargeData{
+		TransactionID:     response.TransactionID,
+		TransactionStatus: response.TransactionStatus,
+		PaymentType:       response.PaymentType,
+		BillKey:           response.BillKey,
+		BillerCode:        response.BillerCode,
+		QRString:          response.QRString,
+	}
+
+	switch {
+	case len(response.VaNumbers) > 0:
+		charge.Bank = response.VaNumbers[0].Bank
+		charge.VANumber = response.VaNumbers[0].VANumber
+	case response.PermataVaNumber != "":
+		charge.Bank = constants.BankPermata
+		charge.VANumber = response.PermataVaNumber
+	}
+
+	for _, action := range response.Actions {
+		if action.Name == "generate-qr-code" {
+			charge.QRCodeURL = action.URL
+		}
+	}
+
+	return charge, nil
+}
+
+func (m *MidtransClient) Refund(
+	orderID string,
+	refundKey string,
+	amount money.Money,
+	reason string,
+) (*MidtransRefundData, error) {
+	refundAmount, amountErr := m.grossAmount(amount)
+	if amountErr != nil {
+		return nil, amountErr
+	}
+
+	coreClient := m.coreClient()
+	response, err := coreClient.RefundTransaction(orderID, &coreapi.RefundReq{
+		RefundKey: refundKey,
+		Amount:    refundAmount,
+		Reason:    reason,
+	})
+	if err != nil {
+		logrus.Errorf("Error refund transaction: %v", err)
+		return nil, errConstants.ErrRefundFailed
+	}
+
+	if response.StatusCode != "200" {
+		logrus.Errorf("Error refund transaction: %s %s", response.StatusCode, response.StatusMessage)
+		return nil, errConstants.ErrRefundFailed
+	}
+
+	return &MidtransRefundData{
+		RefundKey:         response.RefundKey,
+		RefundAmount:      response.RefundAmount,
+		TransactionStatus: response.TransactionStatus,
+	}, nil
+}
+
+func (m *MidtransClient) Cancel(orderID string) (*MidtransTransactionData, error) {
+	coreClient := m.coreClient()
+	response, err := coreClient.CancelTransaction(orderID)
+	return m.transactionData(orderID, response, err)
+}
+
+func (m *MidtransClient) Expire(orderID string) (*MidtransTransactionData, error) {
+	coreClient := m.coreClient()
+	response, err := coreClient.ExpireTransaction(orderID)
+	return m.transactionData(orderID, response, err)
+}
+
+// transactionData treats a 404 as success: Midtrans only knows a Snap
+// transaction once the customer has picked a payment method.
+func (m *MidtransClient) transactionData(
+	orderID string,
+	response *coreapi.ChargeResponse,
+	err *midtrans.Error,
+) (*MidtransTransactionData, error) {
+	if err != nil {
+		if err.GetStatusCode() == http.StatusNotFound {
+			logrus.Infof("transaction %s not found in midtrans", orderID)
+			return &MidtransTransactionData{}, nil
+		}
+
+		logrus.Errorf("Error update transaction %s: %v", orderID, err)
+		return nil, err
+	}
+
+	return &MidtransTransactionData{
+		TransactionID:     response.TransactionID,
+		TransactionStatus: response.TransactionStatus,
+		StatusCode:        response.StatusCode,
+	}, nil
+}
+
+// GetStatus fetches the transaction status in the same shape as a webhook
+// notification, or nil when Midtrans does not know the order yet.
+func (m *MidtransClient) GetStatus(orderID string) (*dto.Webhook, error) {
+	coreClient := m.coreClient()
+	response, err := coreClient.CheckTransaction(orderID)
+	if err != nil {
+		if err.GetStatusCode() == http.StatusNotFound {
+			return nil, nil
+		}
+
+		logrus.Errorf("Error get transaction status %s: %v", orderID, err)
+		return nil, err
+	}
+
+	payload, _ := json.Marshal(response)
+	var webhook dto.Webhook
+	jsonErr := json.Unmarshal(payload, &webhook)
+	if jsonErr != nil {
+		logrus.Errorf("Error parse transaction status %s: %v", orderID, jsonErr)
+		return nil, jsonErr
+	}
+
+	for _, paymentAmount := range response.PaymentAmounts {
+		webhook.PaymentAmount = append(webhook.PaymentAmount, dto.PaymentAmount{
+			PaidAt: &paymentAmount.PaidAt,
+			Amount: &paymentAmount.Amount,
+		})
+	}
+
+	if response.Issuer == "" {
+		webhook.Issuer = nil
+	}
+
+	if response.Acquirer == "" {
+		webhook.Acquirer = nil
+	}
+
+	webhook.RawPayload = payload
+	return &webhook, nil
+}
@@ -0,0 +1,153 @@
+package clients
+
+import (
+	"payment-service/constants"
+	errConstants "payment-service/constants/error/payment"
+	"payment-service/domain/dto"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/midtrans/midtrans-go/snap"
+	"github.com/sirupsen/logrus"
+)
+
+// vaNumberLength is the longest custom VA number each bank accepts, Permata
+// only accepts exactly this length.
+var vaNumberLength = map[string]int{
+	constants.BankBCA:     11,
+	constants.BankBNI:     8,
+	constants.BankBRI:     13,
+	constants.BankPermata: 10,
+}
+
+func vaMethod(bank string) string {
+	return bank + "_va"
+}
+
+// allowed reports whether the method is in the configured allow-list, an
+// empty allow-list allows every method.
+func (m *MidtransClient) allowed(method string) bool {
+	return len(m.EnabledPayments) == 0 || slices.Contains(m.EnabledPayments, method)
+}
+
+// enabledPayments returns the methods the Snap page offers: the requested
+// ones, or the allow-list when none are requested, with the VAs of the
+// preferred banks first. Nil offers every method on the account.
+func (m *MidtransClient) enabledPayments(request *dto.PaymentRequest) ([]snap.SnapPaymentType, error) {
+	methods := request.EnabledPayments
+	if len(methods) == 0 {
+		methods = m.EnabledPayments
+	}
+
+	ordered := make([]string, 0, len(methods)+len(request.BankPreferences))
+	for _, bank := range request.BankPreferences {
+		ordered = append(ordered, vaMethod(bank))
+	}
+	for _, method := range methods {
+		if !slices.Contains(ordered, method) {
+			ordered = append(ordered, method)
+		}
+	}
+
+	if len(ordered) == 0 {
+		return nil, nil
+	}
+
+	enabledPayments := make([]snap.SnapPaymentType, 0, len(ordered))
+	for _, method := range ordered {
+		if !m.allowed(method) {
+			logrus.Errorf("payment method %s is not enabled for order %s", method, request.OrderID)
+			return nil, errConstants.ErrMethodNotAllowed
+		}
+
+		enabledPayments = append(enabledPayments, snap.SnapPaymentType(method))
+	}
+
+	return enabledPayments, nil
+}
+
+// paymentOptions validates the per method options and returns them by method.
+// A custom VA number must fit the bank, and a method expiry must end before
+// the payment does.
+func (m *MidtransClient) paymentOptions(request *dto.PaymentRequest) (map[string]dto.PaymentMethodOption, error) {
+	options := make(map[string]dto.PaymentMethodOption, len(request.PaymentOptions))
+	for _, option := range request.PaymentOptions {
+		if !m.allowed(option.Method) {
+			logrus.Errorf("payment method %s is not enabled for order %s", option.Method, request.OrderID)
+			return nil, errConstants.ErrMethodNotAllowed
+		}
+
+		if option.VANumber != "" {
+			bank, isVA := strings.CutSuffix(option.Method, "_va")
+			maxLength := vaNumberLength[bank]
+			if !isVA || maxLength == 0 || len(option.VANumber) > maxLength ||
+				(bank == constants.BankPermata && len(option.VANumber) != maxLength) {
+				logrus.Errorf("custom va number %s is not valid for %s", option.VANumber, option.Method)
+				return nil, errConstants.ErrCustomVANumber
+			}
+		}
+
+		if option.ExpiredAt != nil &&
+			(!option.ExpiredAt.After(time.Now()) || option.ExpiredAt.After(request.ExpiredAt)) {
+			logrus.Errorf("expiry of %s is not before the payment expiry", option.Method)
+			return nil, errConstants.ErrMethodExpiry
+		}
+
+		options[option.Method] = option
+	}
+
+	return options, nil
+}
+
+// applySnapOptions sets the custom VA numbers on the Snap request. Snap has a
+// single expiry for the page, so a method expiry is only accepted when that
+// method is the only one offered, and then replaces the payment expiry.
+func (m *MidtransClient) applySnapOptions(
+	req *snap.Request,
+	options map[string]dto.PaymentMethodOption,
+	expiredAt time.Time,
+) (time.Time, error) {
+	for method, option := range options {
+		if len(req.EnabledPayments) > 0 && !slices.Contains(req.EnabledPayments, snap.SnapPaymentType(method)) {
+			logrus.Errorf("payment option %s is not one of the enabled payments", method)
+			return expiredAt, errConstants.ErrMethodNotAllowed
+		}
+
+		switch method {
+		case vaMethod(constants.BankBCA):
+			req.BcaVa = &snap.BcaVa{VaNumber: option.VANumber}
+		case vaMethod(constants.BankBNI):
+			req.BniVa = &snap.BniVa{VaNumber: option.VANumber}
+		case vaMethod(constants.BankBRI):
+			req.BriVa = &snap.BriVa{VaNumber: option.VANumber}
+		case vaMethod(constants.BankPermata):
+			req.PermataVa = &snap.PermataVa{VaNumber: option.VANumber}
+		}
+
+		if option.ExpiredAt == nil {
+			continue
+		}
+
+		if len(req.EnabledPayments) != 1 {
+			logrus.Errorf("expiry of %s needs it to be the only enabled payment", method)
+			return expiredAt, errConstants.ErrMethodExpiry
+		}
+
+		expiredAt = *option.ExpiredAt
+	}
+
+	return expiredAt, nil
+}
+
+// chargeMethod is the allow-list name of a Core API charge.
+func chargeMethod(paymentType, bank string) string {
+	switch paymentType {
+	case constants.BankTransfer:
+		return vaMethod(bank)
+	case constants.Qris:
+		return "other_qris"
+	default:
+		return paymentType
+	}
+}
@@ -10,3 +10,27 @@ type MidtransData struct {
 	Token       string `json:"token"`
 	RedirectURL string `json:"redirect_url"`
 }
+
+type MidtransChargeData struct {
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	PaymentType       string `json:"payment_type"`
+	Bank              string `json:"bank"`
+	VANumber          string `json:"va_number"`
+	BillKey           string `json:"bill_key"`
+	BillerCode        string `json:"biller_code"`
+	QRString          string `json:"qr_string"`
+	QRCodeURL         string `json:"qr_code_url"`
+}
+
+type MidtransRefundData struct {
+	RefundKey         string `json:"refund_key"`
+	RefundAmount      string `json:"refund_amount"`
+	TransactionStatus string `json:"transaction_status"`
+}
+
+type MidtransTransactionData struct {
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	StatusCode        string `json:"status_code"`
+}
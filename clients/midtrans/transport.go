@@ -0,0 +1,24 @@
+package clients
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// baseURLTransport sends the SDK requests, whose host is fixed per
+// environment, to the configured base URL instead. Snap and Core API share
+// the base URL, their paths don't overlap.
+type baseURLTransport struct {
+	baseURL *url.URL
+	next    http.RoundTripper
+}
+
+func (b *baseURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = b.baseURL.Scheme
+	req.URL.Host = b.baseURL.Host
+	req.URL.Path = strings.TrimSuffix(b.baseURL.Path, "/") + req.URL.Path
+	req.Host = b.baseURL.Host
+	return b.next.RoundTrip(req)
+}
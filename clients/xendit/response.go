@@ -0,0 +1,33 @@
+package clients
+
+import "github.com/FaisalABR/payment-service/pkg/money"
+
+type XenditInvoice struct {
+	ID                 string      `json:"id"`
+	ExternalID         string      `json:"external_id"`
+	Status             string      `json:"status"`
+	Amount             money.Money `json:"amount"`
+	PaidAmount         money.Money `json:"paid_amount"`
+	Currency           string      `json:"currency"`
+	InvoiceURL         string      `json:"invoice_url"`
+	PaymentMethod      string      `json:"payment_method"`
+	PaymentChannel     string      `json:"payment_channel"`
+	PaymentDestination string      `json:"payment_destination"`
+	BankCode           string      `json:"bank_code"`
+	PaidAt             string      `json:"paid_at"`
+	Created            string      `json:"created"`
+	ExpiryDate         string      `json:"expiry_date"`
+}
+
+type XenditRefund struct {
+	ID          string      `json:"id"`
+	InvoiceID   string      `json:"invoice_id"`
+	ReferenceID string      `json:"reference_id"`
+	Amount      money.Money `json:"amount"`
+	Status      string      `json:"status"`
+}
+
+type XenditError struct {
+	ErrorCode string `json:"error_code"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,226 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"payment-service/clients/config"
+	"payment-service/constants"
+	errConstants "payment-service/constants/error/payment"
+	"payment-service/domain/dto"
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/sirupsen/logrus"
+)
+
+type XenditClient struct {
+	client config.IClientConfig
+}
+
+type IXenditClient interface {
+	CreateInvoice(*dto.PaymentRequest) (*XenditInvoice, error)
+	GetInvoiceByExternalID(string) (*XenditInvoice, error)
+	ExpireInvoice(string) (*XenditInvoice, error)
+	Refund(string, string, money.Money, string) (*XenditRefund, error)
+}
+
+type invoiceRequest struct {
+	ExternalID      string           `json:"external_id"`
+	Amount          money.Money      `json:"amount"`
+	Description     string           `json:"description,omitempty"`
+	InvoiceDuration int64            `json:"invoice_duration"`
+	Currency        string           `json:"currency"`
+	Customer        *invoiceCustomer `json:"customer,omitempty"`
+	Items           []invoiceItem    `json:"items,omitempty"`
+	Fees            []invoiceFee     `json:"fees,omitempty"`
+}
+
+type invoiceCustomer struct {
+	GivenNames   string `json:"given_names,omitempty"`
+	Email        string `json:"email,omitempty"`
+	MobileNumber string `json:"mobile_number,omitempty"`
+}
+
+type invoiceItem struct {
+	ReferenceID string      `json:"reference_id"`
+	Name        string      `json:"name"`
+	Quantity    int         `json:"quantity"`
+	Price       money.Money `json:"price"`
+}
+
+type invoiceFee struct {
+	Type  string      `json:"type"`
+	Value money.Money `json:"value"`
+}
+
+type refundRequest struct {
+	InvoiceID   string            `json:"invoice_id"`
+	ReferenceID string            `json:"reference_id"`
+	Amount      money.Money       `json:"amount"`
+	Reason      string            `json:"reason"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+func NewXenditClient(client config.IClientConfig) IXenditClient {
+	return &XenditClient{
+		client: client,
+	}
+}
+
+// do sends the request with the secret key as the basic auth username, which
+// is how Xendit expects API keys to be sent, and decodes the response into
+// result.
+func (x *XenditClient) do(method, path string, body, result any) error {
+	request := x.client.Client().Clone().
+		SetBasicAuth(x.client.SignatureKey(), "").
+		CustomMethod(method, fmt.Sprintf("%s%s", x.client.BaseURL(), path))
+	if body != nil {
+		request = request.Send(body)
+	}
+
+	resps, responseBody, errs := request.EndBytes()
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	if resps.StatusCode < 200 || resps.StatusCode >= 300 {
+		var xenditError XenditError
+		_ = json.Unmarshal(responseBody, &xenditError)
+		return fmt.Errorf("xendit response %d: %s %s", resps.StatusCode, xenditError.ErrorCode, xenditError.Message)
+	}
+
+	return json.Unmarshal(responseBody, result)
+}
+
+func (x *XenditClient) invoiceRequest(request *dto.PaymentRequest) *invoiceRequest {
+	req := &invoiceRequest{
+		ExternalID:      request.OrderID,
+		Amount:          request.Amount,
+		InvoiceDuration: int64(time.Until(request.ExpiredAt).Seconds()),
+		Currency:        request.Amount.Currency,
+	}
+
+	if request.Description != nil {
+		req.Description = *request.Description
+	}
+
+	if request.CustomerDetail != nil {
+		req.Customer = &invoiceCustomer{
+			GivenNames:   request.CustomerDetail.Name,
+			Email:        request.CustomerDetail.Email,
+			MobileNumber: request.CustomerDetail.Phone,
+		}
+	}
+
+	for _, item := range request.ItemDetails {
+		req.Items = append(req.Items, invoiceItem{
+			ReferenceID: item.ID,
+			Name:        item.Name,
+			Quantity:    item.Quantity,
+			Price:       item.Amount.Round(),
+		})
+	}
+
+	// Xendit has no discount line, a discount is a fee with a negative value.
+	for _, adjustment := range request.Adjustments {
+		value := adjustment.Amount.Round()
+		if adjustment.Type == constants.AdjustmentDiscount {
+			value = value.Neg()
+		}
+
+		req.Fees = append(req.Fees, invoiceFee{
+			Type:  adjustment.Name,
+			Value: value,
+		})
+	}
+
+	return req
+}
+
+func (x *XenditClient) CreateInvoice(request *dto.PaymentRequest) (*XenditInvoice, error) {
+	if !request.ExpiredAt.After(time.Now()) {
+		logrus.Errorf("ExpiredAt is invalid")
+		return nil, errConstants.ErrExpiredAt
+	}
+
+	if request.Amount.Round() != request.Amount {
+		logrus.Errorf("amount %s is not in whole rupiah", request.Amount)
+		return nil, errConstants.ErrFractionalAmount
+	}
+
+	if len(request.LineItems()) > 0 {
+		itemTotal, err := request.ItemTotal()
+		if err != nil || itemTotal.Amount != request.Amount.Amount {
+			logrus.Errorf("item total %s (%v) does not match amount %s for order %s",
+				itemTotal, err, request.Amount, request.OrderID)
+			return nil, errConstants.ErrItemTotalMismatch
+		}
+	}
+
+	var invoice XenditInvoice
+	err := x.do(http.MethodPost, "/v2/invoices", x.invoiceRequest(request), &invoice)
+	if err != nil {
+		logrus.Errorf("Error create invoice: %v", err)
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+// GetInvoiceByExternalID returns the invoice created for the order, or nil
+// when Xendit has none.
+func (x *XenditClient) GetInvoiceByExternalID(externalID string) (*XenditInvoice, error) {
+	var invoices []XenditInvoice
+	path := fmt.Sprintf("/v2/invoices?external_id=%s", url.QueryEscape(externalID))
+	err := x.do(http.MethodGet, path, nil, &invoices)
+	if err != nil {
+		logrus.Errorf("Error get invoice %s: %v", externalID, err)
+		return nil, err
+	}
+
+	if len(invoices) == 0 {
+		return nil, nil
+	}
+
+	return &invoices[0], nil
+}
+
+func (x *XenditClient) ExpireInvoice(invoiceID string) (*XenditInvoice, error) {
+	var invoice XenditInvoice
+	err := x.do(http.MethodPost, fmt.Sprintf("/invoices/%s/expire!", invoiceID), nil, &invoice)
+	if err != nil {
+		logrus.Errorf("Error expire invoice %s: %v", invoiceID, err)
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+func (x *XenditClient) Refund(
+	invoiceID string,
+	referenceID string,
+	amount money.Money,
+	reason string,
+) (*XenditRefund, error) {
+	if amount.Round() != amount {
+		logrus.Errorf("refund amount %s is not in whole rupiah", amount)
+		return nil, errConstants.ErrFractionalAmount
+	}
+
+	var refund XenditRefund
+	err := x.do(http.MethodPost, "/refunds", &refundRequest{
+		InvoiceID:   invoiceID,
+		ReferenceID: referenceID,
+		Amount:      amount,
+		Reason:      "REQUESTED_BY_CUSTOMER",
+		Metadata:    map[string]string{"reason": reason},
+	}, &refund)
+	if err != nil {
+		logrus.Errorf("Error refund invoice %s: %v", invoiceID, err)
+		return nil, errConstants.ErrRefundFailed
+	}
+
+	return &refund, nil
+}
@@ -1,69 +1,78 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os/signal"
 	"payment-service/clients"
+	clientsCallback "payment-service/clients/callback"
+	clientConfig "payment-service/clients/config"
+	clientsGateway "payment-service/clients/gateway"
 	clientsMidtrans "payment-service/clients/midtrans"
+	clientsXendit "payment-service/clients/xendit"
 	gcs "payment-service/common/gcs"
 	"payment-service/common/response"
 	"payment-service/config"
 	"payment-service/constants"
 	controllers "payment-service/controllers/http"
 	"payment-service/controllers/kafka"
+	kafkaOrder "payment-service/controllers/kafka/order"
 	"payment-service/domain/models"
 	"payment-service/middlewares"
+	"payment-service/migrations"
 	"payment-service/repositories"
 	"payment-service/routes"
+	"payment-service/schedulers"
 	"payment-service/services"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/didip/tollbooth"
 	"github.com/didip/tollbooth/limiter"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"gorm.io/gorm"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 var command = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the server",
 	Run: func(c *cobra.Command, args []string) {
-		_ = godotenv.Load()
-		config.Init()
-		db, err := config.InitDatabase()
-		if err != nil {
-			panic(err)
-		}
-
-		loc, err := time.LoadLocation("Asia/Jakarta")
-		if err != nil {
-			panic(err)
-		}
+		db := initDatabase()
+		kafkaRegistry := kafka.NewKafkaRegistry(config.Config.Kafka.Brokers)
+		defer kafkaRegistry.Close()
 
-		time.Local = loc
+		service := initServices(db, kafkaRegistry)
+		client := clients.NewClientRegistry()
+		controller := controllers.NewControllerRegistry(service)
 
-		err = db.AutoMigrate(
-			&models.Payment{},
-			&models.PaymentHistory{},
-		)
-		if err != nil {
-			panic(err)
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		scheduler := schedulers.NewSchedulerRegistry(service)
+		if config.Config.Scheduler.Enabled {
+			go scheduler.Start(ctx)
+		} else {
+			// Events only reach Kafka through the outbox, so the relay runs even
+			// when the other jobs are left to a separate scheduler process.
+			logrus.Warn("scheduler is disabled, running the outbox relay only")
+			go schedulers.RunJob(ctx, scheduler.GetOutboxRelay())
 		}
 
-		gcs := initGCS()
-		client := clients.NewClientRegistry()
-		kafka := kafka.NewKafkaRegistry(config.Config.Kafka.Brokers)
-		midtrans := clientsMidtrans.NewMidtransClient(
-			config.Config.Midtrans.ServerKey,
-			config.Config.Midtrans.IsProduction)
-		repository := repositories.NewRepositoryRegistry(db)
-		service := services.NewServiceRegistry(repository, gcs, kafka, midtrans)
-		controller := controllers.NewControllerRegistry(service)
+		if config.Config.Kafka.ConsumerEnabled {
+			go startConsumer(ctx, service, kafkaRegistry)
+		}
 
 		router := gin.Default()
 		router.Use(middlewares.HandlePanic())
+		router.Use(middlewares.RequestID())
 		router.NoRoute(func(c *gin.Context) {
 			c.JSON(http.StatusNotFound, response.Response{
 				Status:  constants.Error,
@@ -81,7 +90,7 @@ var command = &cobra.Command{
 		router.Use(func(c *gin.Context) {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, x-service-name, x-api-key, x-request-at")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, x-service-name, x-api-key, x-request-at, x-request-id, idempotency-key")
 			if c.Request.Method == "OPTIONS" {
 				c.AbortWithStatus(204)
 				return
@@ -99,12 +108,37 @@ var command = &cobra.Command{
 		route := routes.NewRouteRegistry(controller, group, client)
 		route.Serve()
 
-		port := fmt.Sprintf(":%d", config.Config.Port)
-		router.Run(port)
+		server := &http.Server{
+			Addr:    fmt.Sprintf(":%d", config.Config.Port),
+			Handler: router,
+		}
+
+		go func() {
+			err := server.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				panic(err)
+			}
+		}()
 
+		<-ctx.Done()
+		logrus.Info("shutting down server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			logrus.Errorf("failed to shutdown server: %v", err)
+		}
 	},
 }
 
+func init() {
+	command.AddCommand(schedulerCommand)
+	command.AddCommand(reconcileCommand)
+	command.AddCommand(replayCommand)
+	command.AddCommand(midtransSimulatorCommand)
+}
+
 func Run() {
 	err := command.Execute()
 	if err != nil {
@@ -112,6 +146,79 @@ func Run() {
 	}
 }
 
+func initDatabase() *gorm.DB {
+	_ = godotenv.Load()
+	config.Init()
+	db, err := config.InitDatabase()
+	if err != nil {
+		panic(err)
+	}
+
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		panic(err)
+	}
+
+	time.Local = loc
+
+	err = migrations.Run(db)
+	if err != nil {
+		panic(err)
+	}
+
+	err = db.AutoMigrate(
+		&models.Payment{},
+		&models.PaymentHistory{},
+		&models.PaymentCustomer{},
+		&models.PaymentItem{},
+		&models.WebhookNotification{},
+		&models.Refund{},
+		&models.OutboxEvent{},
+		&models.CallbackRegistration{},
+		&models.CallbackDelivery{},
+		&models.CallbackAttempt{},
+		&models.IdempotencyKey{},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+func initServices(db *gorm.DB, kafka kafka.IKafkaRegistry) services.IServiceRegistry {
+	gcs := initGCS()
+	midtrans := clientsMidtrans.NewMidtransClient(
+		config.Config.Midtrans.ServerKey,
+		config.Config.Midtrans.IsProduction,
+		config.Config.Midtrans.BaseURL,
+		config.Config.Midtrans.EnabledPayments)
+	xendit := clientsXendit.NewXenditClient(clientConfig.NewClientConfig(
+		clientConfig.WithBaseURL(config.Config.Xendit.BaseURL),
+		clientConfig.WithSignatureKey(config.Config.Xendit.SecretKey),
+	))
+	gateways := clientsGateway.NewGatewayRegistry(
+		midtrans,
+		config.Config.Midtrans.ServerKey,
+		xendit,
+		config.Config.Xendit.CallbackToken)
+	callback := clientsCallback.NewCallbackClient(clientConfig.NewClientConfig())
+	repository := repositories.NewRepositoryRegistry(db)
+	return services.NewServiceRegistry(repository, gcs, kafka, gateways, callback)
+}
+
+func startConsumer(ctx context.Context, service services.IServiceRegistry, kafkaRegistry kafka.IKafkaRegistry) {
+	orderHandler := kafkaOrder.NewOrderHandler(service)
+	consumer := kafka.NewKafkaConsumer(kafkaRegistry.GetKafkaProducer())
+	consumer.Register(constants.OrderCreated, orderHandler.OrderCreated)
+	consumer.Register(constants.OrderCancelled, orderHandler.OrderCancelled)
+
+	err := consumer.Start(ctx)
+	if err != nil {
+		logrus.Errorf("consumer stopped: %v", err)
+	}
+}
+
 func initGCS() gcs.IGCSClient {
 	stringPrivateKey := strings.ReplaceAll(config.Config.GCSPrivateKey, `\n`, "\n")
 	gcsServiceAccount := gcs.ServiceAccountKeyJSON{
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/signal"
+	"payment-service/config"
+	"payment-service/internal/midtranssim"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var midtransSimulatorCommand = &cobra.Command{
+	Use:   "midtrans-simulator",
+	Short: "Run a fake Midtrans for local development",
+	Run: func(c *cobra.Command, args []string) {
+		_ = godotenv.Load()
+		config.Init()
+
+		port, _ := c.Flags().GetInt("port")
+		webhookURL, _ := c.Flags().GetString("webhook-url")
+		if webhookURL == "" {
+			webhookURL = fmt.Sprintf("http://localhost:%d/api/v1/payment/webhook", config.Config.Port)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		server := &http.Server{
+			Addr:    fmt.Sprintf(":%d", port),
+			Handler: midtranssim.New(config.Config.Midtrans.ServerKey, webhookURL),
+		}
+
+		go func() {
+			err := server.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				panic(err)
+			}
+		}()
+
+		logrus.Infof("midtrans simulator listening on :%d, notifying %s", port, webhookURL)
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			logrus.Errorf("failed to shutdown midtrans simulator: %v", err)
+		}
+	},
+}
+
+func init() {
+	midtransSimulatorCommand.Flags().Int("port", 8090, "port the simulator listens on")
+	midtransSimulatorCommand.Flags().String("webhook-url", "", "where notifications are sent, defaults to this service's /payment/webhook")
+}
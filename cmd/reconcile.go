@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"payment-service/config"
+	"payment-service/controllers/kafka"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var reconcileCommand = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Sync unpaid payments with the Midtrans transaction status",
+	Run: func(c *cobra.Command, args []string) {
+		olderThan, _ := c.Flags().GetDuration("older-than")
+		limit, _ := c.Flags().GetInt("limit")
+		output, _ := c.Flags().GetString("output")
+
+		db := initDatabase()
+		kafkaRegistry := kafka.NewKafkaRegistry(config.Config.Kafka.Brokers)
+		defer kafkaRegistry.Close()
+
+		service := initServices(db, kafkaRegistry)
+
+		report, err := service.GetPayment().Reconcile(context.Background(), olderThan, limit)
+		if err != nil {
+			panic(err)
+		}
+
+		reportJSON, _ := json.MarshalIndent(report, "", "  ")
+		if output == "" {
+			os.Stdout.Write(append(reportJSON, '\n'))
+			return
+		}
+
+		err = os.WriteFile(output, reportJSON, 0o644)
+		if err != nil {
+			panic(err)
+		}
+
+		logrus.Infof("reconciliation report written to %s", output)
+	},
+}
+
+func init() {
+	reconcileCommand.Flags().Duration("older-than", 15*time.Minute, "only check payments not updated for this long")
+	reconcileCommand.Flags().Int("limit", 100, "maximum number of payments to check")
+	reconcileCommand.Flags().String("output", "", "write the JSON report to this file instead of stdout")
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"payment-service/config"
+	"payment-service/controllers/kafka"
+	"payment-service/domain/dto"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const replayDateLayout = "2006-01-02"
+
+var replayCommand = &cobra.Command{
+	Use:   "replay",
+	Short: "Republish payment events rebuilt from the payment histories",
+	Run: func(c *cobra.Command, args []string) {
+		orderIDs, _ := c.Flags().GetStringSlice("order-id")
+		from, _ := c.Flags().GetString("from")
+		to, _ := c.Flags().GetString("to")
+		topic, _ := c.Flags().GetString("topic")
+		dryRun, _ := c.Flags().GetBool("dry-run")
+
+		db := initDatabase()
+		kafkaRegistry := kafka.NewKafkaRegistry(config.Config.Kafka.Brokers)
+		defer kafkaRegistry.Close()
+
+		service := initServices(db, kafkaRegistry)
+
+		request := &dto.ReplayRequest{
+			OrderIDs: orderIDs,
+			From:     parseReplayTime(from),
+			To:       parseReplayTime(to),
+			Topic:    topic,
+			DryRun:   dryRun,
+		}
+
+		kafkaMessages, err := service.GetPayment().Replay(context.Background(), request)
+		if err != nil {
+			panic(err)
+		}
+
+		if dryRun {
+			kafkaMessagesJSON, _ := json.MarshalIndent(kafkaMessages, "", "  ")
+			os.Stdout.Write(append(kafkaMessagesJSON, '\n'))
+			return
+		}
+
+		logrus.Infof("%d events queued for replay, the outbox relay will publish them", len(kafkaMessages))
+	},
+}
+
+// parseReplayTime accepts either an RFC3339 timestamp or a plain date in the
+// local timezone.
+func parseReplayTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		parsed, err = time.ParseInLocation(replayDateLayout, value, time.Local)
+		if err != nil {
+			logrus.Fatalf("invalid time %q, use %s or RFC3339", value, replayDateLayout)
+		}
+	}
+
+	return &parsed
+}
+
+func init() {
+	replayCommand.Flags().StringSlice("order-id", nil, "order ids to replay, can be repeated or comma separated")
+	replayCommand.Flags().String("from", "", "replay history entries created at or after this time")
+	replayCommand.Flags().String("to", "", "replay history entries created before this time")
+	replayCommand.Flags().String("topic", "", "topic to republish to, defaults to the configured kafka topic")
+	replayCommand.Flags().Bool("dry-run", false, "print the rebuilt events instead of publishing them")
+}
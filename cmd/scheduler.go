@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+	"os/signal"
+	"payment-service/config"
+	"payment-service/controllers/kafka"
+	"payment-service/schedulers"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+var schedulerCommand = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Run the background jobs without the HTTP server",
+	Run: func(c *cobra.Command, args []string) {
+		once, _ := c.Flags().GetBool("once")
+
+		db := initDatabase()
+		kafkaRegistry := kafka.NewKafkaRegistry(config.Config.Kafka.Brokers)
+		defer kafkaRegistry.Close()
+
+		service := initServices(db, kafkaRegistry)
+		registry := schedulers.NewSchedulerRegistry(service)
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		if once {
+			for _, job := range registry.Jobs() {
+				schedulers.RunJobOnce(ctx, job)
+			}
+			return
+		}
+
+		registry.Start(ctx)
+	},
+}
+
+func init() {
+	schedulerCommand.Flags().Bool("once", false, "run every job a single time and exit")
+}
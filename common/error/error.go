@@ -14,7 +14,18 @@ type ValidationResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
-var ErrValidator = map[string]string{}
+var ErrValidator = map[string]string{
+	"uuid":         "%s must be a valid UUID",
+	"gt":           "%s must be greater than %s",
+	"gte":          "%s must be greater than or equal to %s",
+	"min":          "%s must be at least %s",
+	"oneof":        "%s must be one of [%s]",
+	"url":          "%s must be a valid URL",
+	"unique":       "%s must not contain duplicates",
+	"numeric":      "%s must contain digits only",
+	"item_total":   "%s must equal the sum of item price times quantity",
+	"whole_rupiah": "%s must be a whole rupiah amount",
+}
 
 func ErrValidationResponse(err error) (validationResponse []ValidationResponse) {
 	var fieldErrors validator.ValidationErrors
@@ -2,16 +2,25 @@ package util
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"html/template"
 	"math"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
-	"text/template"
+	"time"
 
+	"github.com/FaisalABR/payment-service/pkg/money"
 	"github.com/SebastiaanKlippert/go-wkhtmltopdf"
 	"github.com/dustin/go-humanize"
 	"github.com/sirupsen/logrus"
@@ -73,11 +82,123 @@ func GenerateSHA256(inputString string) string {
 	return hashString
 }
 
-func FormatRupiah(amount *float64) string {
+func GenerateSHA512(inputString string) string {
+	hash := sha512.New()
+	hash.Write([]byte(inputString))
+	hashBytes := hash.Sum(nil)
+	hashString := hex.EncodeToString(hashBytes)
+
+	return hashString
+}
+
+func GenerateHMACSHA256(secret, inputString string) string {
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(inputString))
+	hashBytes := hash.Sum(nil)
+	hashString := hex.EncodeToString(hashBytes)
+
+	return hashString
+}
+
+// encryptedSecretPrefix marks a secret sealed by EncryptSecret and the
+// version of its format.
+const encryptedSecretPrefix = "enc:v1:"
+
+var (
+	ErrSecretKey       = errors.New("secret key must be 16, 24 or 32 hex encoded bytes")
+	ErrEncryptedSecret = errors.New("invalid encrypted secret")
+)
+
+// EncryptSecret seals the secret with AES-GCM under the hex encoded key.
+func EncryptSecret(hexKey, secret string) (string, error) {
+	aead, err := secretCipher(hexKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret opens a secret sealed by EncryptSecret. Anything else,
+// including a plaintext secret, is rejected with ErrEncryptedSecret.
+func DecryptSecret(hexKey, stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, encryptedSecretPrefix)
+	if !ok {
+		return "", ErrEncryptedSecret
+	}
+
+	aead, err := secretCipher(hexKey)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrEncryptedSecret
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
+
+func secretCipher(hexKey string) (cipher.AEAD, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, ErrSecretKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrSecretKey
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// ExponentialBackoff doubles base for every attempt already made, capped at
+// max.
+func ExponentialBackoff(attempts int, base, max time.Duration) time.Duration {
+	backoff := base
+	for i := 0; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+
+	if backoff > max {
+		return max
+	}
+
+	return backoff
+}
+
+// FormatRupiah formats the amount the Indonesian way, "Rp 10.000", with the
+// sen after a comma only when there are any: "Rp 10.000,50".
+func FormatRupiah(amount *money.Money) string {
 	stringValue := "0"
 	if amount != nil {
-		humanizeValue := humanize.CommafWithDigits(*amount, 0)
-		stringValue = strings.ReplaceAll(humanizeValue, ",", ".")
+		decimal := amount.Decimal()
+		sign := ""
+		if strings.HasPrefix(decimal, "-") {
+			sign, decimal = "-", decimal[1:]
+		}
+
+		whole, fraction, hasFraction := strings.Cut(decimal, ".")
+		major, _ := strconv.ParseInt(whole, 10, 64)
+		stringValue = sign + strings.ReplaceAll(humanize.Comma(major), ",", ".")
+		if hasFraction {
+			stringValue += "," + fraction + strings.Repeat("0", money.Exponent-len(fraction))
+		}
 	}
 
 	return fmt.Sprintf("Rp %s", stringValue)
@@ -182,23 +303,33 @@ func add1(a int) int {
 	return a + 1
 }
 
-func GeneratePDFFromHTML(html string, data any) ([]byte, error) {
+// fillHTMLTemplate executes the template with html/template, so values such
+// as customer names and item descriptions are escaped instead of being
+// rendered as markup.
+func fillHTMLTemplate(html string, data any) (string, error) {
 	funcMap := template.FuncMap{
 		"add1": add1,
 	}
 
-	template, err := template.New("htmlTemplate").Funcs(funcMap).Parse(html)
+	htmlTemplate, err := template.New("htmlTemplate").Funcs(funcMap).Parse(html)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	var filledTemplate bytes.Buffer
-	err = template.Execute(&filledTemplate, data)
+	err = htmlTemplate.Execute(&filledTemplate, data)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	htmlContent := filledTemplate.String()
+	return filledTemplate.String(), nil
+}
+
+func GeneratePDFFromHTML(html string, data any) ([]byte, error) {
+	htmlContent, err := fillHTMLTemplate(html, data)
+	if err != nil {
+		return nil, err
+	}
 
 	pdfGenerator, err := wkhtmltopdf.NewPDFGenerator()
 	if err != nil {
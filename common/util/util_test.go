@@ -0,0 +1,85 @@
+package util
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testSecretKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestEncryptSecret(t *testing.T) {
+	secret := "order-service-callback-secret"
+
+	stored, err := EncryptSecret(testSecretKey, secret)
+	if err != nil {
+		t.Fatalf("EncryptSecret() error = %v", err)
+	}
+
+	if strings.Contains(stored, secret) || !strings.HasPrefix(stored, encryptedSecretPrefix) {
+		t.Fatalf("EncryptSecret() = %q, want the secret encrypted", stored)
+	}
+
+	again, _ := EncryptSecret(testSecretKey, secret)
+	if again == stored {
+		t.Errorf("EncryptSecret() reused its nonce")
+	}
+
+	decrypted, err := DecryptSecret(testSecretKey, stored)
+	if err != nil || decrypted != secret {
+		t.Errorf("DecryptSecret() = %q, %v, want %q", decrypted, err, secret)
+	}
+
+	otherKey := strings.Repeat("ab", 32)
+	_, err = DecryptSecret(otherKey, stored)
+	if err == nil {
+		t.Errorf("DecryptSecret() with another key succeeded")
+	}
+
+	tampered := stored[:len(stored)-2] + "AA"
+	_, err = DecryptSecret(testSecretKey, tampered)
+	if err == nil {
+		t.Errorf("DecryptSecret() of a tampered secret succeeded")
+	}
+}
+
+func TestDecryptSecretRejectsPlaintext(t *testing.T) {
+	for _, stored := range []string{"order-service-callback-secret", "", "enc:v2:c2VjcmV0"} {
+		_, err := DecryptSecret(testSecretKey, stored)
+		if !errors.Is(err, ErrEncryptedSecret) {
+			t.Errorf("DecryptSecret(%q) error = %v, want %v", stored, err, ErrEncryptedSecret)
+		}
+	}
+}
+
+func TestEncryptSecretInvalidKey(t *testing.T) {
+	for _, key := range []string{"", "not-hex", "0011"} {
+		_, err := EncryptSecret(key, "secret")
+		if err != ErrSecretKey {
+			t.Errorf("EncryptSecret(%q) error = %v, want %v", key, err, ErrSecretKey)
+		}
+	}
+}
+
+func TestFillHTMLTemplateEscapesData(t *testing.T) {
+	html := `<p>{{ .data.customer.name }}</p>{{ range $index, $item := .data.items }}<b>{{ add1 $index }}. {{ $item.description }}</b>{{ end }}`
+	data := map[string]any{
+		"data": map[string]any{
+			"customer": map[string]any{"name": `<script>alert("x")</script>`},
+			"items":    []any{map[string]any{"description": `<img src=x onerror=alert(1)>`}},
+		},
+	}
+
+	filled, err := fillHTMLTemplate(html, data)
+	if err != nil {
+		t.Fatalf("fillHTMLTemplate() error = %v", err)
+	}
+
+	if strings.Contains(filled, "<script>") || strings.Contains(filled, "<img") {
+		t.Errorf("fillHTMLTemplate() = %q, want the data escaped", filled)
+	}
+
+	if !strings.Contains(filled, "&lt;script&gt;") || !strings.Contains(filled, "<b>1. &lt;img") {
+		t.Errorf("fillHTMLTemplate() = %q, want the escaped data rendered", filled)
+	}
+}
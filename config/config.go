@@ -14,6 +14,7 @@ type AppConfig struct {
 	AppName                    string          `json:"appName"`
 	AppEnv                     string          `json:"appEnv"`
 	SignatureKey               string          `json:"signatureKey"`
+	CallbackSecretKey          string          `json:"callbackSecretKey"`
 	Database                   Database        `json:"database"`
 	RateLimiterMaxRequest      float64         `json:"rateLimiterMaxRequest"`
 	RateLimiterTimeSecond      int             `json:"rateLimiterTimeSecond"`
@@ -32,6 +33,8 @@ type AppConfig struct {
 	GCSBucketName              string          `json:"gcsBucketName"`
 	Kafka                      Kafka           `json:"kafka"`
 	Midtrans                   Midtrans        `json:"midtrans"`
+	Xendit                     Xendit          `json:"xendit"`
+	Scheduler                  Scheduler       `json:"scheduler"`
 }
 
 type Database struct {
@@ -56,16 +59,51 @@ type User struct {
 }
 
 type Kafka struct {
-	Brokers     []string `json:"brokers"`
-	TimeoutInMS int      `json:"timeoutInMs"`
-	MaxRetry    int      `json:"maxRetry"`
-	Topic       string   `json:"topic"`
+	Brokers         []string `json:"brokers"`
+	TimeoutInMS     int      `json:"timeoutInMs"`
+	MaxRetry        int      `json:"maxRetry"`
+	Topic           string   `json:"topic"`
+	ConsumerEnabled bool     `json:"consumerEnabled"`
+	ConsumerGroup   string   `json:"consumerGroup"`
+	ConsumerTopics  []string `json:"consumerTopics"`
+	DeadLetterTopic string   `json:"deadLetterTopic"`
+	// TopicFormats selects the encoding per topic: legacy (default),
+	// cloudevents-structured or cloudevents-binary.
+	TopicFormats      map[string]string `json:"topicFormats"`
+	CloudEventsSource string            `json:"cloudEventsSource"`
 }
 
 type Midtrans struct {
 	ServerKey    string `json:"serverKey"`
 	ClientKey    string `json:"clientKey"`
 	IsProduction bool   `json:"isProduction"`
+	// BaseURL overrides the Midtrans API URL, e.g. to run against the
+	// simulator. Empty uses the sandbox or production URL.
+	BaseURL string `json:"baseURL"`
+	// EnabledPayments is the allow-list of Snap payment methods a payment
+	// may offer. Empty allows every method enabled on the account.
+	EnabledPayments []string `json:"enabledPayments"`
+}
+
+type Xendit struct {
+	BaseURL       string `json:"baseURL"`
+	SecretKey     string `json:"secretKey"`
+	CallbackToken string `json:"callbackToken"`
+}
+
+type Scheduler struct {
+	Enabled                    bool `json:"enabled"`
+	ExpiryIntervalInSeconds    int  `json:"expiryIntervalInSeconds"`
+	ExpiryBatchSize            int  `json:"expiryBatchSize"`
+	ExpiryGraceInSeconds       int  `json:"expiryGraceInSeconds"`
+	ReconcileIntervalInSeconds int  `json:"reconcileIntervalInSeconds"`
+	ReconcileAfterInMinutes    int  `json:"reconcileAfterInMinutes"`
+	ReconcileBatchSize         int  `json:"reconcileBatchSize"`
+	OutboxIntervalInSeconds    int  `json:"outboxIntervalInSeconds"`
+	OutboxBatchSize            int  `json:"outboxBatchSize"`
+	CallbackIntervalInSeconds  int  `json:"callbackIntervalInSeconds"`
+	CallbackBatchSize          int  `json:"callbackBatchSize"`
+	CallbackMaxAttempts        int  `json:"callbackMaxAttempts"`
 }
 
 func Init() {
@@ -20,7 +20,9 @@ func InitDatabase() (*gorm.DB, error) {
 		config.Database.Port,
 		config.Database.Name)
 
-	db, err := gorm.Open(postgres.Open(uri), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(uri), &gorm.Config{
+		TranslateError: true,
+	})
 	if err != nil {
 		logrus.Errorf("Error connecting to database, %s", err)
 		return nil, err
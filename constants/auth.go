@@ -1,5 +1,6 @@
 package constants
 
 const (
-	Token = "token"
+	Token     = "token"
+	RequestID = "requestID"
 )
@@ -0,0 +1,9 @@
+package constants
+
+type CallbackDeliveryStatus string
+
+const (
+	CallbackPending   CallbackDeliveryStatus = "pending"
+	CallbackDelivered CallbackDeliveryStatus = "delivered"
+	CallbackFailed    CallbackDeliveryStatus = "failed"
+)
@@ -0,0 +1,15 @@
+package error
+
+import "errors"
+
+var (
+	ErrCallbackNotFound = errors.New("callback registration not found")
+	ErrDeliveryNotFound = errors.New("callback delivery not found")
+	ErrUnknownEvent     = errors.New("callback subscribes to an unknown event")
+)
+
+var CallbackErrors = []error{
+	ErrCallbackNotFound,
+	ErrDeliveryNotFound,
+	ErrUnknownEvent,
+}
@@ -1,17 +1,20 @@
 package error
 
 import (
+	errCallback "payment-service/constants/error/callback"
 	errPayment "payment-service/constants/error/payment"
 )
 
 func ErrMapping(err error) bool {
 	var (
-		GeneralErrors = GeneralErrors
-		PaymentErrors = errPayment.PaymentErrors
+		GeneralErrors  = GeneralErrors
+		PaymentErrors  = errPayment.PaymentErrors
+		CallbackErrors = errCallback.CallbackErrors
 	)
 	allErrors := make([]error, 0)
 	allErrors = append(allErrors, GeneralErrors...)
 	allErrors = append(allErrors, PaymentErrors...)
+	allErrors = append(allErrors, CallbackErrors...)
 
 	for _, item := range allErrors {
 		if err.Error() == item.Error() {
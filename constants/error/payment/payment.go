@@ -3,11 +3,52 @@ package error
 import "errors"
 
 var (
-	ErrPaymentNotFound = errors.New("payment not found")
-	ErrExpiredAt       = errors.New("expired time must be greater than current time")
+	ErrPaymentNotFound   = errors.New("payment not found")
+	ErrExpiredAt         = errors.New("expired time must be greater than current time")
+	ErrInvalidSignature  = errors.New("invalid webhook signature")
+	ErrUnknownStatus     = errors.New("unknown payment status")
+	ErrInvalidStatus     = errors.New("invalid payment status transition")
+	ErrRefundAmount      = errors.New("refund amount must be greater than zero and not exceed the refundable amount")
+	ErrRefundFailed      = errors.New("failed to refund payment")
+	ErrReplayFilter      = errors.New("replay needs order ids or a valid date range")
+	ErrItemTotalMismatch = errors.New("sum of item price times quantity must equal the amount")
+	ErrUnknownGateway    = errors.New("unknown payment gateway")
+	ErrInvalidPayload    = errors.New("invalid notification payload")
+	ErrGatewayMismatch   = errors.New("notification gateway does not match the payment gateway")
+	ErrUnsupportedMode   = errors.New("payment mode is not supported by the gateway")
+	ErrMethodNotAllowed  = errors.New("payment method is not enabled")
+	ErrCustomVANumber    = errors.New("custom va number is not valid for the bank")
+	ErrMethodExpiry      = errors.New("invalid payment method expiry")
+	ErrUnsupportedOption = errors.New("payment method options are not supported by the gateway")
+	ErrFractionalAmount  = errors.New("amount must be in whole rupiah")
+	ErrDuplicateWebhook  = errors.New("notification was already received")
+
+	ErrPaymentAlreadyExists     = errors.New("payment for this order already exists")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
 )
 
 var PaymentErrors = []error{
 	ErrPaymentNotFound,
 	ErrExpiredAt,
+	ErrInvalidSignature,
+	ErrUnknownStatus,
+	ErrInvalidStatus,
+	ErrRefundAmount,
+	ErrRefundFailed,
+	ErrReplayFilter,
+	ErrItemTotalMismatch,
+	ErrUnknownGateway,
+	ErrInvalidPayload,
+	ErrGatewayMismatch,
+	ErrUnsupportedMode,
+	ErrMethodNotAllowed,
+	ErrCustomVANumber,
+	ErrMethodExpiry,
+	ErrUnsupportedOption,
+	ErrFractionalAmount,
+	ErrDuplicateWebhook,
+	ErrPaymentAlreadyExists,
+	ErrIdempotencyKeyReused,
+	ErrIdempotencyKeyInProgress,
 }
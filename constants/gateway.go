@@ -0,0 +1,8 @@
+package constants
+
+type PaymentGateway string
+
+const (
+	GatewayMidtrans PaymentGateway = "midtrans"
+	GatewayXendit   PaymentGateway = "xendit"
+)
@@ -0,0 +1,20 @@
+package constants
+
+const (
+	KafkaHeaderEventName     = "event-name"
+	KafkaHeaderSchemaVersion = "schema-version"
+	KafkaHeaderCorrelationID = "correlation-id"
+	KafkaHeaderError         = "error"
+	KafkaHeaderSourceTopic   = "source-topic"
+
+	OrderCreated   = "ORDER_CREATED"
+	OrderCancelled = "ORDER_CANCELLED"
+)
+
+type KafkaFormat string
+
+const (
+	KafkaFormatLegacy                KafkaFormat = "legacy"
+	KafkaFormatCloudEventsStructured KafkaFormat = "cloudevents-structured"
+	KafkaFormatCloudEventsBinary     KafkaFormat = "cloudevents-binary"
+)
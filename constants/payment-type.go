@@ -0,0 +1,35 @@
+package constants
+
+const (
+	BankTransfer = "bank_transfer"
+	Echannel     = "echannel"
+	CreditCard   = "credit_card"
+	Gopay        = "gopay"
+	ShopeePay    = "shopeepay"
+	Qris         = "qris"
+	Cstore       = "cstore"
+	EWallet      = "ewallet"
+
+	BankBCA     = "bca"
+	BankBNI     = "bni"
+	BankBRI     = "bri"
+	BankPermata = "permata"
+	BankMandiri = "mandiri"
+)
+
+// PaymentMode selects how the customer pays: on the Snap page, or with the
+// payment details from a Core API charge shown by our own app.
+type PaymentMode string
+
+const (
+	PaymentModeSnap PaymentMode = "snap"
+	PaymentModeCore PaymentMode = "core"
+)
+
+type AdjustmentType string
+
+const (
+	AdjustmentDiscount AdjustmentType = "discount"
+	AdjustmentFee      AdjustmentType = "fee"
+	AdjustmentTax      AdjustmentType = "tax"
+)
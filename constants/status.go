@@ -4,35 +4,99 @@ type PaymentStatus int
 type PaymentStatusString string
 
 const (
-	Initial    PaymentStatus = 0
-	Pending    PaymentStatus = 100
-	Settlement PaymentStatus = 200
-	Expired    PaymentStatus = 300
-
-	InitialString    PaymentStatusString = "initial"
-	PendingString    PaymentStatusString = "pending"
-	SettlementString PaymentStatusString = "settlement"
-	ExpiredString    PaymentStatusString = "expired"
+	Initial        PaymentStatus = 0
+	Pending        PaymentStatus = 100
+	Authorize      PaymentStatus = 110
+	Capture        PaymentStatus = 150
+	Settlement     PaymentStatus = 200
+	Expired        PaymentStatus = 300
+	Deny           PaymentStatus = 310
+	Cancel         PaymentStatus = 320
+	Failure        PaymentStatus = 330
+	Refund         PaymentStatus = 400
+	PartialRefund  PaymentStatus = 410
+	AmountMismatch PaymentStatus = 500
+
+	InitialString        PaymentStatusString = "initial"
+	PendingString        PaymentStatusString = "pending"
+	AuthorizeString      PaymentStatusString = "authorize"
+	CaptureString        PaymentStatusString = "capture"
+	SettlementString     PaymentStatusString = "settlement"
+	ExpiredString        PaymentStatusString = "expired"
+	DenyString           PaymentStatusString = "deny"
+	CancelString         PaymentStatusString = "cancel"
+	FailureString        PaymentStatusString = "failure"
+	RefundString         PaymentStatusString = "refund"
+	PartialRefundString  PaymentStatusString = "partial_refund"
+	AmountMismatchString PaymentStatusString = "amount_mismatch"
+
+	// ExpireString is how Midtrans reports an expiry, it maps to Expired
+	// which is stored and published as "expired".
+	ExpireString PaymentStatusString = "expire"
 )
 
 var mapPaymentStatusStringToInt = map[PaymentStatusString]PaymentStatus{
-	InitialString:    Initial,
-	PendingString:    Pending,
-	SettlementString: Settlement,
-	ExpiredString:    Expired,
+	InitialString:        Initial,
+	PendingString:        Pending,
+	AuthorizeString:      Authorize,
+	CaptureString:        Capture,
+	SettlementString:     Settlement,
+	ExpiredString:        Expired,
+	ExpireString:         Expired,
+	DenyString:           Deny,
+	CancelString:         Cancel,
+	FailureString:        Failure,
+	RefundString:         Refund,
+	PartialRefundString:  PartialRefund,
+	AmountMismatchString: AmountMismatch,
 }
 
 var mapPaymentStatusIntToString = map[PaymentStatus]PaymentStatusString{
-	Initial:    InitialString,
-	Pending:    PendingString,
-	Settlement: SettlementString,
-	Expired:    ExpiredString,
+	Initial:        InitialString,
+	Pending:        PendingString,
+	Authorize:      AuthorizeString,
+	Capture:        CaptureString,
+	Settlement:     SettlementString,
+	Expired:        ExpiredString,
+	Deny:           DenyString,
+	Cancel:         CancelString,
+	Failure:        FailureString,
+	Refund:         RefundString,
+	PartialRefund:  PartialRefundString,
+	AmountMismatch: AmountMismatchString,
+}
+
+// allowedPaymentStatusTransitions lists, for every status, the statuses a
+// payment may move to next. Statuses without an entry are terminal.
+var allowedPaymentStatusTransitions = map[PaymentStatus][]PaymentStatus{
+	Initial:        {Pending, Authorize, Capture, Settlement, Expired, Deny, Cancel, Failure, AmountMismatch},
+	Pending:        {Authorize, Capture, Settlement, Expired, Deny, Cancel, Failure, AmountMismatch},
+	Authorize:      {Capture, Settlement, Expired, Deny, Cancel, Failure, AmountMismatch},
+	Capture:        {Settlement, Deny, Cancel, Refund, PartialRefund, AmountMismatch},
+	Settlement:     {Refund, PartialRefund},
+	PartialRefund:  {PartialRefund, Refund},
+	AmountMismatch: {Refund, PartialRefund, Cancel},
 }
 
 func (p PaymentStatus) GetStatusString() PaymentStatusString {
 	return mapPaymentStatusIntToString[p]
 }
 
+func (p PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, status := range allowedPaymentStatusTransitions[p] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (p PaymentStatusString) GetStatus() PaymentStatus {
 	return mapPaymentStatusStringToInt[p]
 }
+
+func (p PaymentStatusString) IsValid() bool {
+	_, ok := mapPaymentStatusStringToInt[p]
+	return ok
+}
@@ -0,0 +1,76 @@
+package constants
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPaymentStatusStringGetStatus(t *testing.T) {
+	tests := []struct {
+		status    PaymentStatusString
+		want      PaymentStatus
+		wantValid bool
+	}{
+		{status: PendingString, want: Pending, wantValid: true},
+		{status: SettlementString, want: Settlement, wantValid: true},
+		{status: ExpiredString, want: Expired, wantValid: true},
+		{status: ExpireString, want: Expired, wantValid: true},
+		{status: PartialRefundString, want: PartialRefund, wantValid: true},
+		{status: "refunded", want: Initial, wantValid: false},
+		{status: "", want: Initial, wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.IsValid(); got != tt.wantValid {
+				t.Errorf("IsValid() = %v, want %v", got, tt.wantValid)
+			}
+
+			if got := tt.status.GetStatus(); got != tt.want {
+				t.Errorf("GetStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaymentStatusExpiredString(t *testing.T) {
+	if got := ExpireString.GetStatus().GetStatusString(); got != ExpiredString {
+		t.Errorf("GetStatusString() = %q, want %q", got, ExpiredString)
+	}
+}
+
+func TestPaymentStatusCanTransitionTo(t *testing.T) {
+	statuses := []PaymentStatus{
+		Initial, Pending, Authorize, Capture, Settlement, Expired, Deny, Cancel, Failure,
+		Refund, PartialRefund, AmountMismatch,
+	}
+
+	// allowed is the state machine as it is specified, every pair missing
+	// from it must be rejected.
+	allowed := map[PaymentStatus][]PaymentStatus{
+		Initial:        {Pending, Authorize, Capture, Settlement, Expired, Deny, Cancel, Failure, AmountMismatch},
+		Pending:        {Authorize, Capture, Settlement, Expired, Deny, Cancel, Failure, AmountMismatch},
+		Authorize:      {Capture, Settlement, Expired, Deny, Cancel, Failure, AmountMismatch},
+		Capture:        {Settlement, Deny, Cancel, Refund, PartialRefund, AmountMismatch},
+		Settlement:     {Refund, PartialRefund},
+		PartialRefund:  {PartialRefund, Refund},
+		AmountMismatch: {Refund, PartialRefund, Cancel},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := false
+			for _, next := range allowed[from] {
+				if next == to {
+					want = true
+				}
+			}
+
+			t.Run(fmt.Sprintf("%s to %s", from.GetStatusString(), to.GetStatusString()), func(t *testing.T) {
+				if got := from.CanTransitionTo(to); got != want {
+					t.Errorf("CanTransitionTo() = %v, want %v", got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package constants
+
+type WebhookNotificationStatus string
+
+const (
+	WebhookReceived  WebhookNotificationStatus = "received"
+	WebhookProcessed WebhookNotificationStatus = "processed"
+	WebhookFailed    WebhookNotificationStatus = "failed"
+	WebhookRejected  WebhookNotificationStatus = "rejected"
+)
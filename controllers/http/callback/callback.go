@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	errValidation "payment-service/common/error"
+	"payment-service/common/response"
+	errCallback "payment-service/constants/error/callback"
+	"payment-service/domain/dto"
+	"payment-service/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type CallbackController struct {
+	services services.IServiceRegistry
+}
+
+type ICallbackController interface {
+	GetAll(*gin.Context)
+	Register(*gin.Context)
+	GetDeliveries(*gin.Context)
+	GetDeliveryByUUID(*gin.Context)
+	Redeliver(*gin.Context)
+}
+
+func NewCallbackController(services services.IServiceRegistry) ICallbackController {
+	return &CallbackController{
+		services: services,
+	}
+}
+
+func (cb *CallbackController) GetAll(c *gin.Context) {
+	results, err := cb.services.GetCallback().GetAll(c)
+	if err != nil {
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  http.StatusInternalServerError,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	response.HttpResponse(response.ParamHTTPResp{
+		Code: http.StatusOK,
+		Data: results,
+		Gin:  c,
+	})
+}
+
+func (cb *CallbackController) Register(c *gin.Context) {
+	var req dto.CallbackRegistrationRequest
+	err := c.ShouldBindJSON(&req)
+	if err != nil {
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  http.StatusBadRequest,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(req)
+	if err != nil {
+		errMessage := http.StatusText(http.StatusUnprocessableEntity)
+		errResponse := errValidation.ErrValidationResponse(err)
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:    http.StatusBadRequest,
+			Message: &errMessage,
+			Data:    errResponse,
+			Error:   err,
+			Gin:     c,
+		})
+		return
+	}
+
+	result, err := cb.services.GetCallback().Register(c, &req)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, errCallback.ErrUnknownEvent) {
+			code = http.StatusBadRequest
+		}
+
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  code,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	response.HttpResponse(response.ParamHTTPResp{
+		Code: http.StatusCreated,
+		Data: result,
+		Gin:  c,
+	})
+}
+
+func (cb *CallbackController) GetDeliveries(c *gin.Context) {
+	var param dto.CallbackDeliveryRequestParam
+	err := c.ShouldBindQuery(&param)
+	if err != nil {
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  http.StatusBadRequest,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	validate := validator.New()
+	err = validate.Struct(param)
+	if err != nil {
+		errMessage := http.StatusText(http.StatusUnprocessableEntity)
+		errResponse := errValidation.ErrValidationResponse(err)
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:    http.StatusBadRequest,
+			Message: &errMessage,
+			Error:   err,
+			Data:    errResponse,
+			Gin:     c,
+		})
+		return
+	}
+
+	results, err := cb.services.GetCallback().GetDeliveries(c, &param)
+	if err != nil {
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  http.StatusInternalServerError,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	response.HttpResponse(response.ParamHTTPResp{
+		Code: http.StatusOK,
+		Data: results,
+		Gin:  c,
+	})
+}
+
+func (cb *CallbackController) GetDeliveryByUUID(c *gin.Context) {
+	uuid := c.Param("uuid")
+	result, err := cb.services.GetCallback().GetDeliveryByUUID(c, uuid)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, errCallback.ErrDeliveryNotFound) {
+			code = http.StatusNotFound
+		}
+
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  code,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	response.HttpResponse(response.ParamHTTPResp{
+		Code: http.StatusOK,
+		Data: result,
+		Gin:  c,
+	})
+}
+
+func (cb *CallbackController) Redeliver(c *gin.Context) {
+	uuid := c.Param("uuid")
+	result, err := cb.services.GetCallback().Redeliver(c, uuid)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, errCallback.ErrDeliveryNotFound) {
+			code = http.StatusNotFound
+		}
+
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  code,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	response.HttpResponse(response.ParamHTTPResp{
+		Code: http.StatusOK,
+		Data: result,
+		Gin:  c,
+	})
+}
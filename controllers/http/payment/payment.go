@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	errValidation "payment-service/common/error"
 	"payment-service/common/response"
+	"payment-service/constants"
+	errPayment "payment-service/constants/error/payment"
 	"payment-service/domain/dto"
 	"payment-service/services"
 
+	"github.com/FaisalABR/payment-service/pkg/money"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
@@ -20,6 +24,9 @@ type IPaymentController interface {
 	GetByUUID(*gin.Context)
 	Create(*gin.Context)
 	Webhook(*gin.Context)
+	Refund(*gin.Context)
+	Cancel(*gin.Context)
+	Expire(*gin.Context)
 }
 
 func NewPaymentController(services services.IServiceRegistry) IPaymentController {
@@ -104,6 +111,8 @@ func (p *PaymentController) Create(c *gin.Context) {
 	}
 
 	validate := validator.New()
+	validate.RegisterStructValidation(dto.ValidatePaymentRequest, dto.PaymentRequest{})
+	validate.RegisterCustomTypeFunc(dto.MoneyValue, money.Money{})
 	err = validate.Struct(req)
 	if err != nil {
 		errMessage := http.StatusText(http.StatusUnprocessableEntity)
@@ -118,10 +127,29 @@ func (p *PaymentController) Create(c *gin.Context) {
 		return
 	}
 
+	req.IdempotencyKey = c.GetHeader(constants.IdempotencyKey)
+	req.ServiceName = c.GetHeader(constants.XServiceName)
 	result, err := p.services.GetPayment().Create(c, &req)
 	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, errPayment.ErrIdempotencyKeyReused) ||
+			errors.Is(err, errPayment.ErrIdempotencyKeyInProgress) ||
+			errors.Is(err, errPayment.ErrPaymentAlreadyExists) {
+			code = http.StatusConflict
+		}
+		if errors.Is(err, errPayment.ErrItemTotalMismatch) ||
+			errors.Is(err, errPayment.ErrExpiredAt) ||
+			errors.Is(err, errPayment.ErrUnsupportedMode) ||
+			errors.Is(err, errPayment.ErrMethodNotAllowed) ||
+			errors.Is(err, errPayment.ErrCustomVANumber) ||
+			errors.Is(err, errPayment.ErrMethodExpiry) ||
+			errors.Is(err, errPayment.ErrUnsupportedOption) ||
+			errors.Is(err, errPayment.ErrFractionalAmount) {
+			code = http.StatusBadRequest
+		}
+
 		response.HttpResponse(response.ParamHTTPResp{
-			Code:  http.StatusInternalServerError,
+			Code:  code,
 			Error: err,
 			Gin:   c,
 		})
@@ -135,8 +163,52 @@ func (p *PaymentController) Create(c *gin.Context) {
 	})
 }
 
+// Webhook receives the notifications of every gateway. The plain
+// /payment/webhook route is kept for Midtrans.
 func (p *PaymentController) Webhook(c *gin.Context) {
-	var req dto.Webhook
+	gateway := constants.PaymentGateway(c.Param("gateway"))
+	if gateway == "" {
+		gateway = constants.GatewayMidtrans
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  http.StatusBadRequest,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	err = p.services.GetPayment().Webhook(c, gateway, body, c.Request.Header)
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errPayment.ErrInvalidSignature):
+			code = http.StatusForbidden
+		case errors.Is(err, errPayment.ErrUnknownGateway):
+			code = http.StatusNotFound
+		case errors.Is(err, errPayment.ErrInvalidPayload):
+			code = http.StatusBadRequest
+		}
+
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  code,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	response.HttpResponse(response.ParamHTTPResp{
+		Code: http.StatusOK,
+		Gin:  c,
+	})
+}
+
+func (p *PaymentController) Refund(c *gin.Context) {
+	var req dto.RefundRequest
 	err := c.ShouldBindJSON(&req)
 	if err != nil {
 		response.HttpResponse(response.ParamHTTPResp{
@@ -148,6 +220,7 @@ func (p *PaymentController) Webhook(c *gin.Context) {
 	}
 
 	validate := validator.New()
+	validate.RegisterCustomTypeFunc(dto.MoneyValue, money.Money{})
 	err = validate.Struct(req)
 	if err != nil {
 		errMessage := http.StatusText(http.StatusUnprocessableEntity)
@@ -155,17 +228,68 @@ func (p *PaymentController) Webhook(c *gin.Context) {
 		response.HttpResponse(response.ParamHTTPResp{
 			Code:    http.StatusBadRequest,
 			Message: &errMessage,
-			Error:   err,
 			Data:    errResponse,
+			Error:   err,
 			Gin:     c,
 		})
 		return
 	}
 
-	err = p.services.GetPayment().Webhook(c, &req)
+	uuid := c.Param("uuid")
+	result, err := p.services.GetPayment().Refund(c, uuid, &req)
 	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errPayment.ErrPaymentNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, errPayment.ErrRefundAmount),
+			errors.Is(err, errPayment.ErrInvalidStatus),
+			errors.Is(err, errPayment.ErrFractionalAmount):
+			code = http.StatusBadRequest
+		case errors.Is(err, errPayment.ErrRefundFailed):
+			code = http.StatusBadGateway
+		}
+
 		response.HttpResponse(response.ParamHTTPResp{
-			Code:  http.StatusInternalServerError,
+			Code:  code,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	response.HttpResponse(response.ParamHTTPResp{
+		Code: http.StatusCreated,
+		Data: result,
+		Gin:  c,
+	})
+}
+
+func (p *PaymentController) Cancel(c *gin.Context) {
+	uuid := c.Param("uuid")
+	result, err := p.services.GetPayment().Cancel(c, uuid)
+	if err != nil {
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  http.StatusBadRequest,
+			Error: err,
+			Gin:   c,
+		})
+		return
+	}
+
+	response.HttpResponse(response.ParamHTTPResp{
+		Code: http.StatusOK,
+		Data: result,
+		Gin:  c,
+	})
+}
+
+func (p *PaymentController) Expire(c *gin.Context) {
+	uuid := c.Param("uuid")
+	result, err := p.services.GetPayment().Expire(c, uuid)
+	if err != nil {
+		response.HttpResponse(response.ParamHTTPResp{
+			Code:  http.StatusBadRequest,
 			Error: err,
 			Gin:   c,
 		})
@@ -174,6 +298,7 @@ func (p *PaymentController) Webhook(c *gin.Context) {
 
 	response.HttpResponse(response.ParamHTTPResp{
 		Code: http.StatusOK,
+		Data: result,
 		Gin:  c,
 	})
 }
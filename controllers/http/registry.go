@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	controllerCallback "payment-service/controllers/http/callback"
 	controllerPayment "payment-service/controllers/http/payment"
 	"payment-service/services"
 )
@@ -11,6 +12,7 @@ type Registry struct {
 
 type IControllerRegistry interface {
 	GetPayment() controllerPayment.IPaymentController
+	GetCallback() controllerCallback.ICallbackController
 }
 
 func NewControllerRegistry(services services.IServiceRegistry) IControllerRegistry {
@@ -22,3 +24,7 @@ func NewControllerRegistry(services services.IServiceRegistry) IControllerRegist
 func (r *Registry) GetPayment() controllerPayment.IPaymentController {
 	return controllerPayment.NewPaymentController(r.services)
 }
+
+func (r *Registry) GetCallback() controllerCallback.ICallbackController {
+	return controllerCallback.NewCallbackController(r.services)
+}
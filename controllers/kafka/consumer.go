@@ -0,0 +1,217 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	configApp "payment-service/config"
+	"payment-service/constants"
+	"payment-service/domain/dto"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+)
+
+type EventHandler func(context.Context, *dto.KafkaConsumerMessage) error
+
+// permanentError is a handler failure that retrying can't fix, such as an
+// invalid message.
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+func (p *permanentError) Unwrap() error {
+	return p.err
+}
+
+// Permanent marks err so the consumer sends the message to the dead-letter
+// topic without retrying it.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+type Consumer struct {
+	brokers         []string
+	group           string
+	topics          []string
+	deadLetterTopic string
+	maxRetry        int
+	handlers        map[string]EventHandler
+	producer        IKafka
+}
+
+type IKafkaConsumer interface {
+	Register(string, EventHandler)
+	Start(context.Context) error
+}
+
+func NewKafkaConsumer(producer IKafka) IKafkaConsumer {
+	return &Consumer{
+		brokers:         configApp.Config.Kafka.Brokers,
+		group:           configApp.Config.Kafka.ConsumerGroup,
+		topics:          configApp.Config.Kafka.ConsumerTopics,
+		deadLetterTopic: configApp.Config.Kafka.DeadLetterTopic,
+		maxRetry:        configApp.Config.Kafka.MaxRetry,
+		handlers:        make(map[string]EventHandler),
+		producer:        producer,
+	}
+}
+
+func (k *Consumer) Register(eventName string, handler EventHandler) {
+	k.handlers[eventName] = handler
+}
+
+// Start joins the consumer group and consumes until the context is cancelled.
+// Offsets are committed only after a message was handled or dead-lettered,
+// so every message is processed at least once.
+func (k *Consumer) Start(ctx context.Context) error {
+	config := sarama.NewConfig()
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Offsets.AutoCommit.Enable = false
+	config.Consumer.Return.Errors = true
+
+	group, err := sarama.NewConsumerGroup(k.brokers, k.group, config)
+	if err != nil {
+		logrus.Errorf("failed to create consumer group: %v", err)
+		return err
+	}
+
+	defer func(group sarama.ConsumerGroup) {
+		err := group.Close()
+		if err != nil {
+			logrus.Errorf("failed to close consumer group: %v", err)
+		}
+	}(group)
+
+	go func() {
+		for err := range group.Errors() {
+			logrus.Errorf("consumer group error: %v", err)
+		}
+	}()
+
+	logrus.Infof("consumer group %s started on topics %v", k.group, k.topics)
+	for ctx.Err() == nil {
+		err = group.Consume(ctx, k.topics, k)
+		if err != nil {
+			if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+				return nil
+			}
+
+			logrus.Errorf("failed to consume: %v", err)
+			time.Sleep(time.Second)
+		}
+	}
+
+	return nil
+}
+
+func (k *Consumer) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (k *Consumer) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (k *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case <-session.Context().Done():
+			return nil
+		case message, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+
+			err := k.handle(session.Context(), message)
+			if err != nil {
+				return err
+			}
+
+			session.MarkMessage(message, "")
+			session.Commit()
+		}
+	}
+}
+
+// handle dispatches the message to its handler, retrying failures and sending
+// messages that still fail, or fail permanently, to the dead-letter topic.
+func (k *Consumer) handle(ctx context.Context, message *sarama.ConsumerMessage) error {
+	var consumerMessage dto.KafkaConsumerMessage
+	err := json.Unmarshal(message.Value, &consumerMessage)
+	if err != nil {
+		logrus.Errorf("failed to decode message at %s/%d/%d: %v",
+			message.Topic, message.Partition, message.Offset, err)
+		return k.deadLetter(message, err)
+	}
+
+	handler, ok := k.handlers[consumerMessage.Event.Name]
+	if !ok {
+		logrus.Debugf("no handler for event %s, skipping", consumerMessage.Event.Name)
+		return nil
+	}
+
+	for attempt := 0; attempt <= k.maxRetry; attempt++ {
+		err = k.callHandler(ctx, handler, &consumerMessage)
+		if err == nil {
+			return nil
+		}
+
+		logrus.Errorf("failed to handle event %s (attempt %d): %v", consumerMessage.Event.Name, attempt+1, err)
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			break
+		}
+
+		time.Sleep(time.Duration(attempt+1) * time.Second)
+	}
+
+	return k.deadLetter(message, err)
+}
+
+func (k *Consumer) callHandler(
+	ctx context.Context,
+	handler EventHandler,
+	message *dto.KafkaConsumerMessage,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("Recovered from panic: %v", r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(ctx, message)
+}
+
+func (k *Consumer) deadLetter(message *sarama.ConsumerMessage, cause error) error {
+	if k.deadLetterTopic == "" {
+		logrus.Errorf("dropping message at %s/%d/%d: no dead-letter topic configured",
+			message.Topic, message.Partition, message.Offset)
+		return nil
+	}
+
+	headers := make(map[string]string, len(message.Headers)+2)
+	for _, header := range message.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+	headers[constants.KafkaHeaderError] = cause.Error()
+	headers[constants.KafkaHeaderSourceTopic] = message.Topic
+
+	return k.producer.Produce(&ProducerMessage{
+		Topic:   k.deadLetterTopic,
+		Key:     string(message.Key),
+		Headers: headers,
+		Value:   message.Value,
+	})
+}
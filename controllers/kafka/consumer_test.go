@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"payment-service/domain/dto"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+type fakeProducer struct {
+	messages []*ProducerMessage
+}
+
+func (f *fakeProducer) Produce(message *ProducerMessage) error {
+	f.messages = append(f.messages, message)
+	return nil
+}
+
+func (f *fakeProducer) Close() error {
+	return nil
+}
+
+func TestConsumerHandleDeadLetters(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+		wantDLQ   bool
+	}{
+		{name: "handled", wantCalls: 1},
+		{name: "transient error is retried", err: errHandler, wantCalls: 2, wantDLQ: true},
+		{name: "permanent error is not retried", err: Permanent(errHandler), wantCalls: 1, wantDLQ: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &fakeProducer{}
+			consumer := &Consumer{
+				deadLetterTopic: "payment-dlq",
+				maxRetry:        1,
+				handlers:        make(map[string]EventHandler),
+				producer:        producer,
+			}
+
+			calls := 0
+			consumer.Register("ORDER_CREATED", func(context.Context, *dto.KafkaConsumerMessage) error {
+				calls++
+				return tt.err
+			})
+
+			err := consumer.handle(context.Background(), &sarama.ConsumerMessage{
+				Topic: "order",
+				Value: []byte(`{"event":{"name":"ORDER_CREATED"},"body":{"data":{}}}`),
+			})
+			if err != nil {
+				t.Fatalf("handle() error = %v", err)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+
+			if gotDLQ := len(producer.messages) == 1; gotDLQ != tt.wantDLQ {
+				t.Errorf("dead-lettered = %v, want %v", gotDLQ, tt.wantDLQ)
+			}
+		})
+	}
+}
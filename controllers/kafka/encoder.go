@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"encoding/json"
+	"payment-service/constants"
+	"time"
+)
+
+const (
+	cloudEventsSpecVersion       = "1.0"
+	cloudEventsContentType       = "application/cloudevents+json"
+	cloudEventsDataContentType   = "application/json"
+	cloudEventsHeaderPrefix      = "ce_"
+	cloudEventsHeaderContentType = "content-type"
+)
+
+// EventMessage is a payment event ready to be published, still in the
+// legacy event/metadata/body shape it was stored with.
+type EventMessage struct {
+	ID            string
+	Topic         string
+	Key           string
+	Name          string
+	SchemaVersion string
+	CorrelationID string
+	Time          time.Time
+	Payload       []byte
+}
+
+type IEncoder interface {
+	Encode(*EventMessage) (*ProducerMessage, error)
+}
+
+type LegacyEncoder struct{}
+
+// CloudEventsEncoder emits CloudEvents 1.0 over Kafka. In structured mode the
+// whole event is the message value, in binary mode the attributes travel as
+// ce_ headers and the value carries only the data.
+type CloudEventsEncoder struct {
+	source string
+	binary bool
+}
+
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	SchemaVersion   string          `json:"schemaversion,omitempty"`
+	CorrelationID   string          `json:"correlationid,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+func NewEncoder(format constants.KafkaFormat, source string) IEncoder {
+	switch format {
+	case constants.KafkaFormatCloudEventsStructured:
+		return &CloudEventsEncoder{source: source}
+	case constants.KafkaFormatCloudEventsBinary:
+		return &CloudEventsEncoder{source: source, binary: true}
+	default:
+		return &LegacyEncoder{}
+	}
+}
+
+func legacyHeaders(req *EventMessage) map[string]string {
+	return map[string]string{
+		constants.KafkaHeaderEventName:     req.Name,
+		constants.KafkaHeaderSchemaVersion: req.SchemaVersion,
+		constants.KafkaHeaderCorrelationID: req.CorrelationID,
+	}
+}
+
+func (l *LegacyEncoder) Encode(req *EventMessage) (*ProducerMessage, error) {
+	return &ProducerMessage{
+		Topic:   req.Topic,
+		Key:     req.Key,
+		Headers: legacyHeaders(req),
+		Value:   req.Payload,
+	}, nil
+}
+
+// data unwraps body.data from the legacy payload, which is what CloudEvents
+// consumers expect as the event data.
+func (c *CloudEventsEncoder) data(payload []byte) (json.RawMessage, error) {
+	var legacy struct {
+		Body struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"body"`
+	}
+
+	err := json.Unmarshal(payload, &legacy)
+	if err != nil {
+		return nil, err
+	}
+
+	return legacy.Body.Data, nil
+}
+
+func (c *CloudEventsEncoder) Encode(req *EventMessage) (*ProducerMessage, error) {
+	data, err := c.data(req.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	event := cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              req.ID,
+		Source:          c.source,
+		Type:            req.Name,
+		Subject:         req.Key,
+		Time:            req.Time.Format(time.RFC3339),
+		DataContentType: cloudEventsDataContentType,
+		SchemaVersion:   req.SchemaVersion,
+		CorrelationID:   req.CorrelationID,
+		Data:            data,
+	}
+
+	headers := legacyHeaders(req)
+	if !c.binary {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+
+		headers[cloudEventsHeaderContentType] = cloudEventsContentType
+		return &ProducerMessage{
+			Topic:   req.Topic,
+			Key:     req.Key,
+			Headers: headers,
+			Value:   value,
+		}, nil
+	}
+
+	headers[cloudEventsHeaderContentType] = event.DataContentType
+	headers[cloudEventsHeaderPrefix+"specversion"] = event.SpecVersion
+	headers[cloudEventsHeaderPrefix+"id"] = event.ID
+	headers[cloudEventsHeaderPrefix+"source"] = event.Source
+	headers[cloudEventsHeaderPrefix+"type"] = event.Type
+	headers[cloudEventsHeaderPrefix+"time"] = event.Time
+	if event.Subject != "" {
+		headers[cloudEventsHeaderPrefix+"subject"] = event.Subject
+	}
+	if event.SchemaVersion != "" {
+		headers[cloudEventsHeaderPrefix+"schemaversion"] = event.SchemaVersion
+	}
+	if event.CorrelationID != "" {
+		headers[cloudEventsHeaderPrefix+"correlationid"] = event.CorrelationID
+	}
+
+	return &ProducerMessage{
+		Topic:   req.Topic,
+		Key:     req.Key,
+		Headers: headers,
+		Value:   data,
+	}, nil
+}
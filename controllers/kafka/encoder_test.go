@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"payment-service/constants"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testData keeps its own spacing so binary mode can be checked to forward
+// the data bytes untouched.
+const testData = `{"orderID": "1f0e8d1c-7b2a-4c3d-9e8f-0a1b2c3d4e5f",  "status": "settlement", "amount": 100000.5}`
+
+func newTestEventMessage() *EventMessage {
+	return &EventMessage{
+		ID:            "42",
+		Topic:         "payment",
+		Key:           "1f0e8d1c-7b2a-4c3d-9e8f-0a1b2c3d4e5f",
+		Name:          "SETTLEMENT",
+		SchemaVersion: "1",
+		CorrelationID: "corr-1",
+		Time:          time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Payload: []byte(`{"event":{"name":"SETTLEMENT"},"metadata":{"schemaVersion":"1"},` +
+			`"body":{"type":"JSON","data":` + testData + `}}`),
+	}
+}
+
+func TestCloudEventsEncoderStructured(t *testing.T) {
+	message, err := NewEncoder(constants.KafkaFormatCloudEventsStructured, "payment-service").
+		Encode(newTestEventMessage())
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if message.Topic != "payment" || message.Key != "1f0e8d1c-7b2a-4c3d-9e8f-0a1b2c3d4e5f" {
+		t.Errorf("Encode() topic, key = %s, %s, want payment and the order id", message.Topic, message.Key)
+	}
+
+	wantHeaders := map[string]string{
+		"content-type":                     "application/cloudevents+json",
+		constants.KafkaHeaderEventName:     "SETTLEMENT",
+		constants.KafkaHeaderSchemaVersion: "1",
+		constants.KafkaHeaderCorrelationID: "corr-1",
+	}
+	if !reflect.DeepEqual(message.Headers, wantHeaders) {
+		t.Errorf("Encode() headers = %v, want %v", message.Headers, wantHeaders)
+	}
+
+	var event map[string]any
+	err = json.Unmarshal(message.Value, &event)
+	if err != nil {
+		t.Fatalf("unmarshal value: %v", err)
+	}
+
+	wantData := map[string]any{
+		"orderID": "1f0e8d1c-7b2a-4c3d-9e8f-0a1b2c3d4e5f",
+		"status":  "settlement",
+		"amount":  100000.5,
+	}
+	wantEvent := map[string]any{
+		"specversion":     "1.0",
+		"id":              "42",
+		"source":          "payment-service",
+		"type":            "SETTLEMENT",
+		"subject":         "1f0e8d1c-7b2a-4c3d-9e8f-0a1b2c3d4e5f",
+		"time":            "2024-05-01T10:00:00Z",
+		"datacontenttype": "application/json",
+		"schemaversion":   "1",
+		"correlationid":   "corr-1",
+		"data":            wantData,
+	}
+	if !reflect.DeepEqual(event, wantEvent) {
+		t.Errorf("Encode() value = %v, want %v", event, wantEvent)
+	}
+}
+
+func TestCloudEventsEncoderBinary(t *testing.T) {
+	tests := []struct {
+		name        string
+		mutate      func(*EventMessage)
+		wantHeaders map[string]string
+	}{
+		{
+			name: "all attributes",
+			wantHeaders: map[string]string{
+				"content-type":                     "application/json",
+				"ce_specversion":                   "1.0",
+				"ce_id":                            "42",
+				"ce_source":                        "payment-service",
+				"ce_type":                          "SETTLEMENT",
+				"ce_time":                          "2024-05-01T10:00:00Z",
+				"ce_subject":                       "1f0e8d1c-7b2a-4c3d-9e8f-0a1b2c3d4e5f",
+				"ce_schemaversion":                 "1",
+				"ce_correlationid":                 "corr-1",
+				constants.KafkaHeaderEventName:     "SETTLEMENT",
+				constants.KafkaHeaderSchemaVersion: "1",
+				constants.KafkaHeaderCorrelationID: "corr-1",
+			},
+		},
+		{
+			name: "optional attributes left out",
+			mutate: func(req *EventMessage) {
+				req.Key = ""
+				req.SchemaVersion = ""
+				req.CorrelationID = ""
+			},
+			wantHeaders: map[string]string{
+				"content-type":                     "application/json",
+				"ce_specversion":                   "1.0",
+				"ce_id":                            "42",
+				"ce_source":                        "payment-service",
+				"ce_type":                          "SETTLEMENT",
+				"ce_time":                          "2024-05-01T10:00:00Z",
+				constants.KafkaHeaderEventName:     "SETTLEMENT",
+				constants.KafkaHeaderSchemaVersion: "",
+				constants.KafkaHeaderCorrelationID: "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestEventMessage()
+			if tt.mutate != nil {
+				tt.mutate(req)
+			}
+
+			message, err := NewEncoder(constants.KafkaFormatCloudEventsBinary, "payment-service").Encode(req)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(message.Headers, tt.wantHeaders) {
+				t.Errorf("Encode() headers = %v, want %v", message.Headers, tt.wantHeaders)
+			}
+
+			if !bytes.Equal(message.Value, []byte(testData)) {
+				t.Errorf("Encode() value = %s, want the data bytes unchanged %s", message.Value, testData)
+			}
+		})
+	}
+}
+
+func TestCloudEventsEncoderRejectsInvalidPayload(t *testing.T) {
+	for _, format := range []constants.KafkaFormat{
+		constants.KafkaFormatCloudEventsStructured,
+		constants.KafkaFormatCloudEventsBinary,
+	} {
+		t.Run(string(format), func(t *testing.T) {
+			req := newTestEventMessage()
+			req.Payload = []byte(`not json`)
+
+			_, err := NewEncoder(format, "payment-service").Encode(req)
+			if err == nil {
+				t.Errorf("Encode() error = nil, want an error for an invalid payload")
+			}
+		})
+	}
+}
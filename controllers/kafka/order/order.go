@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	errPayment "payment-service/constants/error/payment"
+	"payment-service/controllers/kafka"
+	"payment-service/domain/dto"
+	"payment-service/services"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+)
+
+type OrderHandler struct {
+	services services.IServiceRegistry
+}
+
+type IOrderHandler interface {
+	OrderCreated(context.Context, *dto.KafkaConsumerMessage) error
+	OrderCancelled(context.Context, *dto.KafkaConsumerMessage) error
+}
+
+func NewOrderHandler(services services.IServiceRegistry) IOrderHandler {
+	return &OrderHandler{
+		services: services,
+	}
+}
+
+// invalidRequestErrors are the payment errors caused by the request itself,
+// a redelivery of the event would fail the same way.
+var invalidRequestErrors = []error{
+	errPayment.ErrExpiredAt,
+	errPayment.ErrItemTotalMismatch,
+	errPayment.ErrUnknownGateway,
+	errPayment.ErrUnsupportedMode,
+	errPayment.ErrMethodNotAllowed,
+	errPayment.ErrCustomVANumber,
+	errPayment.ErrMethodExpiry,
+	errPayment.ErrUnsupportedOption,
+	errPayment.ErrFractionalAmount,
+}
+
+func permanentIfInvalid(err error) error {
+	for _, invalid := range invalidRequestErrors {
+		if errors.Is(err, invalid) {
+			return kafka.Permanent(err)
+		}
+	}
+
+	return err
+}
+
+// OrderCreated creates the payment for a new order. Redelivered events find
+// the payment already in place and are acknowledged without side effects.
+func (o *OrderHandler) OrderCreated(ctx context.Context, message *dto.KafkaConsumerMessage) error {
+	var req dto.PaymentRequest
+	err := json.Unmarshal(message.Body.Data, &req)
+	if err != nil {
+		return kafka.Permanent(err)
+	}
+
+	validate := validator.New()
+	validate.RegisterStructValidation(dto.ValidatePaymentRequest, dto.PaymentRequest{})
+	validate.RegisterCustomTypeFunc(dto.MoneyValue, money.Money{})
+	err = validate.Struct(req)
+	if err != nil {
+		return kafka.Permanent(err)
+	}
+
+	_, err = o.services.GetPayment().GetByOrderID(ctx, req.OrderID)
+	if err == nil {
+		logrus.Infof("payment for order %s already exists, skipping", req.OrderID)
+		return nil
+	}
+
+	if !errors.Is(err, errPayment.ErrPaymentNotFound) {
+		return err
+	}
+
+	_, err = o.services.GetPayment().Create(ctx, &req)
+	return permanentIfInvalid(err)
+}
+
+// OrderCancelled cancels the payment of the order. A payment that is already
+// closed, e.g. cancelled by a redelivery or settled before the cancel
+// arrived, can't be cancelled and is acknowledged as is.
+func (o *OrderHandler) OrderCancelled(ctx context.Context, message *dto.KafkaConsumerMessage) error {
+	var req dto.OrderCancelledData
+	err := json.Unmarshal(message.Body.Data, &req)
+	if err != nil {
+		return kafka.Permanent(err)
+	}
+
+	validate := validator.New()
+	err = validate.Struct(req)
+	if err != nil {
+		return kafka.Permanent(err)
+	}
+
+	payment, err := o.services.GetPayment().GetByOrderID(ctx, req.OrderID.String())
+	if err != nil {
+		if errors.Is(err, errPayment.ErrPaymentNotFound) {
+			logrus.Infof("no payment for cancelled order %s, skipping", req.OrderID.String())
+			return nil
+		}
+		return err
+	}
+
+	_, err = o.services.GetPayment().Cancel(ctx, payment.UUID.String())
+	if errors.Is(err, errPayment.ErrInvalidStatus) {
+		logrus.Warnf("payment for cancelled order %s is already %s, skipping",
+			req.OrderID.String(), payment.Status)
+		return nil
+	}
+
+	return err
+}
@@ -2,17 +2,29 @@ package kafka
 
 import (
 	configApp "payment-service/config"
+	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/sirupsen/logrus"
 )
 
 type Kafka struct {
-	brokers []string
+	brokers  []string
+	mu       sync.Mutex
+	producer sarama.SyncProducer
+}
+
+type ProducerMessage struct {
+	Topic   string
+	Key     string
+	Headers map[string]string
+	Value   []byte
 }
 
 type IKafka interface {
-	Produce(topic string, data []byte) error
+	Produce(*ProducerMessage) error
+	Close() error
 }
 
 func NewKafkaProducer(brokers []string) IKafka {
@@ -21,30 +33,55 @@ func NewKafkaProducer(brokers []string) IKafka {
 	}
 }
 
-func (k *Kafka) Produce(topic string, data []byte) error {
+// syncProducer creates the producer on first use and keeps it for the
+// lifetime of the process. A failed attempt is retried on the next message.
+func (k *Kafka) syncProducer() (sarama.SyncProducer, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.producer != nil {
+		return k.producer, nil
+	}
+
 	config := sarama.NewConfig()
 	config.Producer.Return.Successes = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = configApp.Config.Kafka.MaxRetry
+	if configApp.Config.Kafka.TimeoutInMS > 0 {
+		config.Producer.Timeout = time.Duration(configApp.Config.Kafka.TimeoutInMS) * time.Millisecond
+	}
 
 	producer, err := sarama.NewSyncProducer(k.brokers, config)
 	if err != nil {
 		logrus.Errorf("failed to create producer: %v", err)
+		return nil, err
+	}
+
+	k.producer = producer
+	return k.producer, nil
+}
+
+func (k *Kafka) Produce(req *ProducerMessage) error {
+	producer, err := k.syncProducer()
+	if err != nil {
 		return err
 	}
 
-	defer func(producer sarama.SyncProducer) {
-		err = producer.Close()
-		if err != nil {
-			logrus.Errorf("failed to close producer: %v", err)
-			return
-		}
-	}(producer)
+	headers := make([]sarama.RecordHeader, 0, len(req.Headers))
+	for key, value := range req.Headers {
+		headers = append(headers, sarama.RecordHeader{
+			Key:   []byte(key),
+			Value: []byte(value),
+		})
+	}
 
 	message := &sarama.ProducerMessage{
-		Topic:   topic,
-		Headers: nil,
-		Value:   sarama.ByteEncoder(data),
+		Topic:   req.Topic,
+		Headers: headers,
+		Value:   sarama.ByteEncoder(req.Value),
+	}
+	if req.Key != "" {
+		message.Key = sarama.StringEncoder(req.Key)
 	}
 
 	partition, offset, err := producer.SendMessage(message)
@@ -53,6 +90,24 @@ func (k *Kafka) Produce(topic string, data []byte) error {
 		return err
 	}
 
-	logrus.Infof("Message sent in topic %s to partition %d at offset %d", topic, partition, offset)
+	logrus.Infof("Message sent in topic %s to partition %d at offset %d", req.Topic, partition, offset)
+	return nil
+}
+
+func (k *Kafka) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.producer == nil {
+		return nil
+	}
+
+	err := k.producer.Close()
+	if err != nil {
+		logrus.Errorf("failed to close producer: %v", err)
+		return err
+	}
+
+	k.producer = nil
 	return nil
 }
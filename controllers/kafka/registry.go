@@ -1,19 +1,41 @@
 package kafka
 
+import (
+	configApp "payment-service/config"
+	"payment-service/constants"
+)
+
 type Registry struct {
-	brokers []string
+	producer IKafka
 }
 
 type IKafkaRegistry interface {
 	GetKafkaProducer() IKafka
+	GetEncoder(string) IEncoder
+	Close() error
 }
 
 func NewKafkaRegistry(brokers []string) IKafkaRegistry {
 	return &Registry{
-		brokers: brokers,
+		producer: NewKafkaProducer(brokers),
 	}
 }
 
 func (r *Registry) GetKafkaProducer() IKafka {
-	return NewKafkaProducer(r.brokers)
+	return r.producer
+}
+
+// GetEncoder returns the encoder configured for the topic, falling back to
+// the legacy format for topics that are not listed.
+func (r *Registry) GetEncoder(topic string) IEncoder {
+	source := configApp.Config.Kafka.CloudEventsSource
+	if source == "" {
+		source = "/" + configApp.Config.AppName
+	}
+
+	return NewEncoder(constants.KafkaFormat(configApp.Config.Kafka.TopicFormats[topic]), source)
+}
+
+func (r *Registry) Close() error {
+	return r.producer.Close()
 }
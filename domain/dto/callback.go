@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"payment-service/constants"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CallbackRegistrationRequest struct {
+	ServiceName string   `json:"serviceName" validate:"required"`
+	URL         string   `json:"url" validate:"required,url"`
+	Secret      string   `json:"secret" validate:"required,min=16"`
+	Events      []string `json:"events" validate:"required,min=1"`
+}
+
+type CallbackRegistrationResponse struct {
+	UUID        uuid.UUID  `json:"uuid"`
+	ServiceName string     `json:"serviceName"`
+	URL         string     `json:"url"`
+	Events      []string   `json:"events"`
+	IsActive    bool       `json:"isActive"`
+	CreatedAt   *time.Time `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
+
+type CallbackDeliveryRequest struct {
+	RegistrationID uint   `json:"registrationID"`
+	AggregateID    string `json:"aggregateID"`
+	EventName      string `json:"eventName"`
+	Payload        string `json:"payload"`
+}
+
+type CallbackAttemptRequest struct {
+	DeliveryID   uint
+	Attempt      int
+	ResponseCode *int
+	Error        *string
+	Duration     time.Duration
+	AttemptedAt  time.Time
+}
+
+type CallbackDeliveryRequestParam struct {
+	Page        int                               `form:"page" validate:"required"`
+	Limit       int                               `form:"limit" validate:"required"`
+	Status      *constants.CallbackDeliveryStatus `form:"status"`
+	AggregateID *string                           `form:"aggregateID"`
+}
+
+type CallbackDeliveryResponse struct {
+	UUID          uuid.UUID                        `json:"uuid"`
+	ServiceName   string                           `json:"serviceName"`
+	URL           string                           `json:"url"`
+	AggregateID   string                           `json:"aggregateID"`
+	EventName     string                           `json:"eventName"`
+	Payload       string                           `json:"payload"`
+	Status        constants.CallbackDeliveryStatus `json:"status"`
+	Attempts      int                              `json:"attempts"`
+	ResponseCode  *int                             `json:"responseCode"`
+	LastError     *string                          `json:"lastError"`
+	NextAttemptAt time.Time                        `json:"nextAttemptAt"`
+	DeliveredAt   *time.Time                       `json:"deliveredAt"`
+	History       []CallbackAttemptResponse        `json:"history,omitempty"`
+	CreatedAt     *time.Time                       `json:"createdAt"`
+	UpdatedAt     *time.Time                       `json:"updatedAt"`
+}
+
+type CallbackAttemptResponse struct {
+	Attempt      int       `json:"attempt"`
+	ResponseCode *int      `json:"responseCode"`
+	Error        *string   `json:"error"`
+	DurationMS   int64     `json:"durationMs"`
+	AttemptedAt  time.Time `json:"attemptedAt"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+type IdempotencyKeyRequest struct {
+	Service     string `json:"service"`
+	Key         string `json:"key"`
+	RequestHash string `json:"requestHash"`
+}
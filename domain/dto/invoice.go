@@ -6,11 +6,18 @@ type InvoiceRequest struct {
 }
 
 type InvoiceData struct {
+	Customer      *InvoiceCustomer     `json:"customer"`
 	PaymentDetail InvoicePaymentDetail `json:"paymentDetail"`
 	Items         []InvoiceItem        `json:"items"`
 	Total         string               `json:"total"`
 }
 
+type InvoiceCustomer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
 type InvoicePaymentDetail struct {
 	BankName      string `json:"bankName"`
 	PaymentMethod string `json:"paymentMethod"`
@@ -21,5 +28,7 @@ type InvoicePaymentDetail struct {
 
 type InvoiceItem struct {
 	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   string `json:"unitPrice"`
 	Price       string `json:"price"`
 }
@@ -1,35 +1,33 @@
 package dto
 
 import (
-	"time"
+	"encoding/json"
 
+	"github.com/FaisalABR/payment-service/pkg/events"
 	"github.com/google/uuid"
 )
 
-type KafkaEvent struct {
-	Name string `json:"name"`
-}
-
-type KafkaMetadata struct {
-	Sender    string `json:"sender"`
-	SendingAt string `json:"sendingAt"`
-}
+// The published payment events are defined by the versioned contract in
+// pkg/events; these aliases keep the dto names used across the service.
+type (
+	KafkaEvent    = events.Event
+	KafkaMetadata = events.Metadata
+	KafkaData     = events.PaymentData
+	KafkaBody     = events.Body
+	KafkaMessage  = events.Envelope
+)
 
-type KafkaData struct {
-	OrderID   uuid.UUID  `json:"orderID"`
-	PaymentID uuid.UUID  `json:"paymentID"`
-	Status    string     `json:"status"`
-	PaidAt    *time.Time `json:"paidAt"`
-	ExpiredAt time.Time  `json:"expiredAt"`
+type KafkaConsumerBody struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
 }
 
-type KafkaBody struct {
-	Type string     `json:"type"`
-	Data *KafkaData `json:"data"`
+type KafkaConsumerMessage struct {
+	Event    KafkaEvent        `json:"event"`
+	Metadata KafkaMetadata     `json:"metadata"`
+	Body     KafkaConsumerBody `json:"body"`
 }
 
-type KafkaMessage struct {
-	Event    KafkaEvent    `json:"event"`
-	Metadata KafkaMetadata `json:"metadata"`
-	Body     KafkaBody     `json:"body"`
+type OrderCancelledData struct {
+	OrderID uuid.UUID `json:"orderID" validate:"required"`
 }
@@ -0,0 +1,10 @@
+package dto
+
+type OutboxEventRequest struct {
+	AggregateID   string  `json:"aggregateID"`
+	Topic         string  `json:"topic"`
+	EventName     string  `json:"eventName"`
+	SchemaVersion string  `json:"schemaVersion"`
+	CorrelationID *string `json:"correlationID"`
+	Payload       string  `json:"payload"`
+}
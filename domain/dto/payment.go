@@ -1,33 +1,169 @@
 package dto
 
 import (
+	"fmt"
 	"payment-service/constants"
+	"reflect"
 	"time"
 
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
 type PaymentRequest struct {
-	PaymentLink    string          `json:"paymentLink"`
-	OrderID        string          `json:"orderID"`
-	ExpiredAt      time.Time       `json:"expiredAt"`
-	Amount         float64         `json:"amount"`
-	Description    *string         `json:"description"`
-	CustomerDetail *CustomerDetail `json:"customerDetail"`
-	ItemDetails    []ItemDetail    `json:"itemDetails"`
+	PaymentLink     string                   `json:"paymentLink"`
+	OrderID         string                   `json:"orderID" validate:"required,uuid"`
+	ExpiredAt       time.Time                `json:"expiredAt" validate:"required"`
+	Amount          money.Money              `json:"amount" validate:"required,gt=0"`
+	Description     *string                  `json:"description"`
+	CustomerDetail  *CustomerDetail          `json:"customerDetail"`
+	ItemDetails     []ItemDetail             `json:"itemDetails" validate:"dive"`
+	Adjustments     []AdjustmentDetail       `json:"adjustments" validate:"dive"`
+	Gateway         constants.PaymentGateway `json:"gateway" validate:"omitempty,oneof=midtrans xendit"`
+	Mode            constants.PaymentMode    `json:"mode" validate:"omitempty,oneof=snap core"`
+	PaymentType     string                   `json:"paymentType" validate:"omitempty,oneof=bank_transfer echannel qris"`
+	Bank            string                   `json:"bank" validate:"omitempty,oneof=bca bni bri permata"`
+	EnabledPayments []string                 `json:"enabledPayments" validate:"omitempty,unique,dive,required"`
+	BankPreferences []string                 `json:"bankPreferences" validate:"omitempty,unique,dive,oneof=bca bni bri permata"`
+	PaymentOptions  []PaymentMethodOption    `json:"paymentOptions" validate:"omitempty,unique=Method,dive"`
+	IdempotencyKey  string                   `json:"-"`
+	ServiceName     string                   `json:"-"`
 }
 
 type CustomerDetail struct {
 	Name  string `json:"name"`
-	Email string `json:"email"`
+	Email string `json:"email" validate:"omitempty,email"`
 	Phone string `json:"phone"`
 }
 
 type ItemDetail struct {
-	ID       string  `json:"id"`
-	Amount   float64 `json:"amount"`
-	Name     string  `json:"name"`
-	Quantity int     `json:"quantity"`
+	ID       string      `json:"id" validate:"required"`
+	Amount   money.Money `json:"amount" validate:"gte=0"`
+	Name     string      `json:"name" validate:"required"`
+	Quantity int         `json:"quantity" validate:"required,min=1"`
+}
+
+// PaymentMethodOption customises one payment method: a custom VA number for
+// the bank VAs, and an expiry that ends before the payment does.
+type PaymentMethodOption struct {
+	Method    string     `json:"method" validate:"required"`
+	VANumber  string     `json:"vaNumber" validate:"omitempty,numeric"`
+	ExpiredAt *time.Time `json:"expiredAt"`
+}
+
+// AdjustmentDetail is a discount, fee or tax on top of the item details.
+// Amount is always positive, discounts are subtracted from the total.
+type AdjustmentDetail struct {
+	Type   constants.AdjustmentType `json:"type" validate:"required,oneof=discount fee tax"`
+	Name   string                   `json:"name" validate:"required"`
+	Amount money.Money              `json:"amount" validate:"required,gt=0"`
+}
+
+// LineItems returns the item details followed by the adjustments as line
+// items, the way they are sent to Midtrans and stored with the payment.
+func (p *PaymentRequest) LineItems() []ItemDetail {
+	lineItems := make([]ItemDetail, 0, len(p.ItemDetails)+len(p.Adjustments))
+	lineItems = append(lineItems, p.ItemDetails...)
+	for _, adjustment := range p.Adjustments {
+		amount := adjustment.Amount
+		if adjustment.Type == constants.AdjustmentDiscount {
+			amount = amount.Neg()
+		}
+
+		lineItems = append(lineItems, ItemDetail{
+			ID:       string(adjustment.Type),
+			Amount:   amount,
+			Name:     adjustment.Name,
+			Quantity: 1,
+		})
+	}
+
+	return lineItems
+}
+
+// ItemTotal is sum(price * quantity) of the line items, which the gateways
+// require to equal the gross amount. It returns money.ErrOverflow when the
+// total doesn't fit.
+func (p *PaymentRequest) ItemTotal() (money.Money, error) {
+	total := money.New(0, p.Amount.Currency)
+	for _, item := range p.LineItems() {
+		subtotal, err := item.Amount.Mul(int64(item.Quantity))
+		if err != nil {
+			return money.Money{}, err
+		}
+
+		total, err = total.Add(subtotal)
+		if err != nil {
+			return money.Money{}, err
+		}
+	}
+
+	return total, nil
+}
+
+// MoneyValue is registered as a custom type func so money fields are
+// validated by their minor units, e.g. gt=0.
+func MoneyValue(field reflect.Value) any {
+	amount, ok := field.Interface().(money.Money)
+	if !ok {
+		return nil
+	}
+
+	return amount.Amount
+}
+
+// ValidatePaymentRequest is registered as a struct level validation so a
+// mismatch between the items and the amount, an item price in sen, or a core
+// charge without its payment type or bank, is reported like any other field
+// error.
+func ValidatePaymentRequest(sl validator.StructLevel) {
+	req := sl.Current().Interface().(PaymentRequest)
+	if req.Mode == constants.PaymentModeCore && req.PaymentType == "" {
+		sl.ReportError(req.PaymentType, "PaymentType", "paymentType", "required", "")
+	}
+
+	if req.PaymentType == constants.BankTransfer && req.Bank == "" && len(req.BankPreferences) == 0 {
+		sl.ReportError(req.Bank, "Bank", "bank", "required", "")
+	}
+
+	if len(req.ItemDetails) == 0 && len(req.Adjustments) == 0 {
+		return
+	}
+
+	// The gateways charge whole rupiah per item, a price in sen would be
+	// rounded there and no longer add up to the amount.
+	for i, item := range req.ItemDetails {
+		if item.Amount.Currency == money.IDR && item.Amount.Round() != item.Amount {
+			sl.ReportError(item.Amount, fmt.Sprintf("ItemDetails[%d].Amount", i), "amount", "whole_rupiah", "")
+		}
+	}
+
+	for i, adjustment := range req.Adjustments {
+		if adjustment.Amount.Currency == money.IDR && adjustment.Amount.Round() != adjustment.Amount {
+			sl.ReportError(adjustment.Amount, fmt.Sprintf("Adjustments[%d].Amount", i), "amount", "whole_rupiah", "")
+		}
+	}
+
+	itemTotal, err := req.ItemTotal()
+	if err != nil || itemTotal.Amount != req.Amount.Amount {
+		sl.ReportError(req.Amount, "Amount", "amount", "item_total", "")
+	}
+}
+
+type PaymentCustomerRequest struct {
+	PaymentID uint   `json:"paymentID"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+}
+
+type PaymentItemRequest struct {
+	PaymentID uint        `json:"paymentID"`
+	ItemID    string      `json:"itemID"`
+	Name      string      `json:"name"`
+	Price     money.Money `json:"price"`
+	Quantity  int         `json:"quantity"`
 }
 
 type PaymentRequestParam struct {
@@ -38,27 +174,58 @@ type PaymentRequestParam struct {
 }
 
 type UpdatePaymentRequest struct {
+	PaymentLink   *string                  `json:"paymentLink"`
 	TransactionID *string                  `json:"transactionID"`
 	Status        *constants.PaymentStatus `json:"status"`
 	PaidAt        *time.Time               `json:"paidAt"`
+	PaymentType   *string                  `json:"paymentType"`
 	VANumber      *string                  `json:"vaNumber"`
+	BillerCode    *string                  `json:"billerCode"`
 	Bank          *string                  `json:"bank"`
+	MaskedCard    *string                  `json:"maskedCard"`
+	CardType      *string                  `json:"cardType"`
+	Store         *string                  `json:"store"`
+	Issuer        *string                  `json:"issuer"`
 	InvoiceLink   *string                  `json:"invoiceLink,omitempty"`
 	Acquirer      *string                  `json:"acquirer"`
+	QRString      *string                  `json:"qrString"`
+}
+
+type PaymentMethodDetail struct {
+	PaymentType *string `json:"paymentType"`
+	VANumber    *string `json:"vaNumber"`
+	BillerCode  *string `json:"billerCode"`
+	Bank        *string `json:"bank"`
+	MaskedCard  *string `json:"maskedCard"`
+	CardType    *string `json:"cardType"`
+	Store       *string `json:"store"`
+	Issuer      *string `json:"issuer"`
+	Acquirer    *string `json:"acquirer"`
 }
 
 type PaymentResponse struct {
 	UUID          uuid.UUID                     `json:"uuid"`
 	OrderID       uuid.UUID                     `json:"orderID"`
-	Amount        float64                       `json:"amount"`
+	Amount        money.Money                   `json:"amount"`
+	Currency      string                        `json:"currency"`
 	Status        constants.PaymentStatusString `json:"status"`
+	Gateway       constants.PaymentGateway      `json:"gateway"`
 	PaymentLink   string                        `json:"paymentLink"`
 	InvoiceLink   *string                       `json:"invoiceLink,omitempty"`
 	TransactionID *string                       `json:"transactionID,omitempty"`
+	PaymentType   *string                       `json:"paymentType,omitempty"`
 	VANumber      *string                       `json:"vaNumber,omitempty"`
+	BillerCode    *string                       `json:"billerCode,omitempty"`
 	Bank          *string                       `json:"bank,omitempty"`
+	MaskedCard    *string                       `json:"maskedCard,omitempty"`
+	CardType      *string                       `json:"cardType,omitempty"`
+	Store         *string                       `json:"store,omitempty"`
+	Issuer        *string                       `json:"issuer,omitempty"`
 	Acquirer      *string                       `json:"acquirer,omitempty"`
+	QRString      *string                       `json:"qrString,omitempty"`
 	Description   *string                       `json:"description,omitempty"`
+	Customer      *CustomerDetail               `json:"customer,omitempty"`
+	Items         []ItemDetail                  `json:"items,omitempty"`
 	PaidAt        *time.Time                    `json:"paidAt,omitempty"`
 	ExpiredAt     *time.Time                    `json:"expiredAt"`
 	UpdatedAt     *time.Time                    `json:"updatedAt"`
@@ -82,6 +249,27 @@ type Webhook struct {
 	FraudStatus       string                        `json:"fraud_status"`
 	Currency          string                        `json:"currency"`
 	Acquirer          *string                       `json:"acquirer"`
+	Issuer            *string                       `json:"issuer"`
+	PermataVANumber   string                        `json:"permata_va_number"`
+	BillKey           string                        `json:"bill_key"`
+	BillerCode        string                        `json:"biller_code"`
+	Bank              string                        `json:"bank"`
+	MaskedCard        string                        `json:"masked_card"`
+	CardType          string                        `json:"card_type"`
+	ApprovalCode      string                        `json:"approval_code"`
+	Store             string                        `json:"store"`
+	PaymentCode       string                        `json:"payment_code"`
+	RefundAmount      string                        `json:"refund_amount"`
+	Refunds           []WebhookRefund               `json:"refunds"`
+	RawPayload        []byte                        `json:"-"`
+}
+
+// WebhookRefund is one refund of the transaction, Midtrans lists every refund
+// made so far in refund notifications and status responses.
+type WebhookRefund struct {
+	RefundKey    string `json:"refund_key"`
+	RefundAmount string `json:"refund_amount"`
+	Reason       string `json:"reason"`
 }
 
 type VANumber struct {
@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"errors"
+	"payment-service/constants"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+func TestValidatePaymentRequestItemPrices(t *testing.T) {
+	validate := validator.New()
+	validate.RegisterStructValidation(ValidatePaymentRequest, PaymentRequest{})
+	validate.RegisterCustomTypeFunc(MoneyValue, money.Money{})
+
+	tests := []struct {
+		name        string
+		items       []ItemDetail
+		adjustments []AdjustmentDetail
+		want        []string
+	}{
+		{
+			name:  "whole rupiah prices",
+			items: []ItemDetail{{ID: "sku-1", Name: "Item", Amount: money.FromMajor(5000, money.IDR), Quantity: 2}},
+		},
+		{
+			name: "item price in sen",
+			items: []ItemDetail{
+				{ID: "sku-1", Name: "Item", Amount: money.FromMajor(5000, money.IDR), Quantity: 1},
+				{ID: "sku-2", Name: "Item", Amount: money.New(499950, money.IDR), Quantity: 1},
+			},
+			adjustments: []AdjustmentDetail{
+				{Type: constants.AdjustmentFee, Name: "Fee", Amount: money.New(50, money.IDR)},
+			},
+			want: []string{
+				"PaymentRequest.ItemDetails[1].Amount whole_rupiah",
+				"PaymentRequest.Adjustments[0].Amount whole_rupiah",
+			},
+		},
+		{
+			name: "prices in sen that add up to whole rupiah",
+			items: []ItemDetail{
+				{ID: "sku-1", Name: "Item", Amount: money.New(249950, money.IDR), Quantity: 1},
+				{ID: "sku-2", Name: "Item", Amount: money.New(750050, money.IDR), Quantity: 1},
+			},
+			want: []string{
+				"PaymentRequest.ItemDetails[0].Amount whole_rupiah",
+				"PaymentRequest.ItemDetails[1].Amount whole_rupiah",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(PaymentRequest{
+				OrderID:     uuid.NewString(),
+				ExpiredAt:   time.Now().Add(time.Hour),
+				Amount:      money.FromMajor(10000, money.IDR),
+				ItemDetails: tt.items,
+				Adjustments: tt.adjustments,
+			})
+
+			var got []string
+			var fieldErrors validator.ValidationErrors
+			if errors.As(err, &fieldErrors) {
+				for _, fieldError := range fieldErrors {
+					got = append(got, fieldError.Namespace()+" "+fieldError.Tag())
+				}
+			} else if err != nil {
+				t.Fatalf("Struct() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct() errors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
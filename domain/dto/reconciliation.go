@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"payment-service/constants"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReconciliationItem struct {
+	OrderID        uuid.UUID                     `json:"orderID"`
+	PreviousStatus constants.PaymentStatusString `json:"previousStatus"`
+	GatewayStatus  constants.PaymentStatusString `json:"gatewayStatus,omitempty"`
+	CurrentStatus  constants.PaymentStatusString `json:"currentStatus"`
+	Changed        bool                          `json:"changed"`
+	Error          *string                       `json:"error,omitempty"`
+}
+
+type ReconciliationReport struct {
+	StartedAt  time.Time            `json:"startedAt"`
+	FinishedAt time.Time            `json:"finishedAt"`
+	Checked    int                  `json:"checked"`
+	Changed    int                  `json:"changed"`
+	Failed     int                  `json:"failed"`
+	Items      []ReconciliationItem `json:"items"`
+}
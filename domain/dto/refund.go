@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"payment-service/constants"
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/google/uuid"
+)
+
+type RefundRequest struct {
+	Amount *money.Money `json:"amount" validate:"omitempty,gt=0"`
+	Reason string       `json:"reason" validate:"required"`
+}
+
+type CreateRefundRequest struct {
+	PaymentID uint                   `json:"paymentID"`
+	RefundKey string                 `json:"refundKey"`
+	Amount    money.Money            `json:"amount"`
+	Reason    string                 `json:"reason"`
+	Status    constants.RefundStatus `json:"status"`
+}
+
+type RefundResponse struct {
+	UUID          uuid.UUID                     `json:"uuid"`
+	PaymentID     uuid.UUID                     `json:"paymentID"`
+	RefundKey     string                        `json:"refundKey"`
+	Amount        money.Money                   `json:"amount"`
+	Currency      string                        `json:"currency"`
+	Reason        string                        `json:"reason"`
+	Status        constants.RefundStatus        `json:"status"`
+	PaymentStatus constants.PaymentStatusString `json:"paymentStatus"`
+	CreatedAt     *time.Time                    `json:"createdAt"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+type ReplayRequest struct {
+	OrderIDs []string
+	From     *time.Time
+	To       *time.Time
+	Topic    string
+	DryRun   bool
+}
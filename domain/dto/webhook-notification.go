@@ -0,0 +1,23 @@
+package dto
+
+import (
+	"payment-service/constants"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookNotificationRequest struct {
+	DedupeKey         string                        `json:"dedupeKey"`
+	Gateway           constants.PaymentGateway      `json:"gateway"`
+	OrderID           uuid.UUID                     `json:"orderID"`
+	TransactionID     string                        `json:"transactionID"`
+	TransactionStatus constants.PaymentStatusString `json:"transactionStatus"`
+	Payload           string                        `json:"payload"`
+}
+
+type UpdateWebhookNotificationRequest struct {
+	Status      constants.WebhookNotificationStatus `json:"status"`
+	Error       *string                             `json:"error"`
+	ProcessedAt *time.Time                          `json:"processedAt"`
+}
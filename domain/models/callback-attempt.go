@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// CallbackAttempt records one attempt to send a callback delivery.
+type CallbackAttempt struct {
+	ID           uint `gorm:"primaryKey;autoIncrement"`
+	DeliveryID   uint `gorm:"type:bigint;not null;index"`
+	Attempt      int  `gorm:"not null"`
+	ResponseCode *int
+	Error        *string   `gorm:"type:text;default: null"`
+	DurationMS   int64     `gorm:"not null"`
+	AttemptedAt  time.Time `gorm:"not null"`
+	CreatedAt    *time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"payment-service/constants"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CallbackDelivery struct {
+	ID             uint                             `gorm:"primaryKey;autoIncrement"`
+	UUID           uuid.UUID                        `gorm:"type:uuid;not null;uniqueIndex"`
+	RegistrationID uint                             `gorm:"type:bigint;not null;index"`
+	AggregateID    string                           `gorm:"type:varchar(255);not null"`
+	EventName      string                           `gorm:"type:varchar(100);not null"`
+	Payload        string                           `gorm:"type:text;not null"`
+	Status         constants.CallbackDeliveryStatus `gorm:"type:varchar(30);not null;index"`
+	Attempts       int                              `gorm:"not null;default:0"`
+	ResponseCode   *int
+	LastError      *string   `gorm:"type:text;default: null"`
+	NextAttemptAt  time.Time `gorm:"not null;index"`
+	DeliveredAt    *time.Time
+	CreatedAt      *time.Time
+	UpdatedAt      *time.Time
+	Registration   CallbackRegistration `gorm:"foreignKey:RegistrationID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	History        []CallbackAttempt    `gorm:"foreignKey:DeliveryID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CallbackRegistration keeps Secret encrypted with the callbackSecretKey.
+type CallbackRegistration struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	UUID        uuid.UUID `gorm:"type:uuid;not null"`
+	ServiceName string    `gorm:"type:varchar(100);not null"`
+	URL         string    `gorm:"type:varchar(255);not null"`
+	Secret      string    `gorm:"type:text;not null"`
+	Events      []string  `gorm:"type:text;serializer:json;not null"`
+	IsActive    bool      `gorm:"not null;default:true"`
+	CreatedAt   *time.Time
+	UpdatedAt   *time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IdempotencyKey is unique per calling service, two services may send the
+// same key for different requests.
+type IdempotencyKey struct {
+	ID          uint    `gorm:"primaryKey;autoIncrement"`
+	Service     string  `gorm:"type:varchar(100);not null;uniqueIndex:idx_idempotency_keys_service_key,priority:1"`
+	Key         string  `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_service_key,priority:2"`
+	RequestHash string  `gorm:"type:varchar(64);not null"`
+	Response    *string `gorm:"type:text;default: null"`
+	CreatedAt   *time.Time
+	UpdatedAt   *time.Time
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxEvent struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	UUID          uuid.UUID `gorm:"type:uuid;not null"`
+	AggregateID   string    `gorm:"type:varchar(255);not null;index"`
+	Topic         string    `gorm:"type:varchar(255);not null"`
+	EventName     string    `gorm:"type:varchar(100);not null"`
+	SchemaVersion string    `gorm:"type:varchar(20);not null"`
+	CorrelationID *string   `gorm:"type:varchar(255);default: null"`
+	Payload       string    `gorm:"type:text;not null"`
+	Attempts      int       `gorm:"not null;default:0"`
+	LastError     *string   `gorm:"type:text;default: null"`
+	NextAttemptAt time.Time `gorm:"not null;index"`
+	DeliveredAt   *time.Time
+	CreatedAt     *time.Time
+	UpdatedAt     *time.Time
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type PaymentCustomer struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	PaymentID uint   `gorm:"type:bigint;not null;uniqueIndex"`
+	Name      string `gorm:"type:varchar(255);not null"`
+	Email     string `gorm:"type:varchar(255);not null"`
+	Phone     string `gorm:"type:varchar(50);not null"`
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+)
+
+type PaymentItem struct {
+	ID        uint        `gorm:"primaryKey;autoIncrement"`
+	PaymentID uint        `gorm:"type:bigint;not null;index"`
+	ItemID    string      `gorm:"type:varchar(255);not null"`
+	Name      string      `gorm:"type:varchar(255);not null"`
+	Price     money.Money `gorm:"embedded;embeddedPrefix:price_"`
+	Quantity  int         `gorm:"not null"`
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+}
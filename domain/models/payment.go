@@ -4,20 +4,29 @@ import (
 	"payment-service/constants"
 	"time"
 
+	"github.com/FaisalABR/payment-service/pkg/money"
 	"github.com/google/uuid"
 )
 
 type Payment struct {
 	ID               int                      `gorm:"primaryKey;autoIncrement"`
 	UUID             uuid.UUID                `gorm:"type:uuid; not null"`
-	OrderID          uuid.UUID                `gorm:"type:uuid; not null"`
-	Amount           float64                  `gorm:"not null"`
+	OrderID          uuid.UUID                `gorm:"type:uuid; not null;uniqueIndex"`
+	Amount           money.Money              `gorm:"embedded"`
 	Status           *constants.PaymentStatus `gorm:"not null"`
+	Gateway          constants.PaymentGateway `gorm:"type:varchar(30);not null;default:'midtrans'"`
 	PaymentLink      string                   `gorm:"type:varchar(255);not null"`
 	InvoiceLink      *string                  `gorm:"type:varchar(255);default: null"`
+	PaymentType      *string                  `gorm:"type:varchar(50);default: null"`
 	VANumber         *string                  `gorm:"type:varchar(255);default: null"`
+	BillerCode       *string                  `gorm:"type:varchar(50);default: null"`
 	Bank             *string                  `gorm:"type:varchar(255);default: null"`
+	MaskedCard       *string                  `gorm:"type:varchar(50);default: null"`
+	CardType         *string                  `gorm:"type:varchar(50);default: null"`
+	Store            *string                  `gorm:"type:varchar(100);default: null"`
+	Issuer           *string                  `gorm:"type:varchar(100);default: null"`
 	Acquirer         *string                  `gorm:"type:varchar(255);default: null"`
+	QRString         *string                  `gorm:"type:text;default: null"`
 	TransactionID    *string                  `gorm:"type:varchar(255);default: null"`
 	Description      *string                  `gorm:"type:text;default: null"`
 	PaidAt           *time.Time
@@ -25,4 +34,7 @@ type Payment struct {
 	CreatedAt        *time.Time
 	UpdatedAt        *time.Time
 	PaymentHistories []PaymentHistory `gorm:"foreignKey:payment_id;references:id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Refunds          []Refund         `gorm:"foreignKey:payment_id;references:id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Customer         *PaymentCustomer `gorm:"foreignKey:payment_id;references:id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Items            []PaymentItem    `gorm:"foreignKey:payment_id;references:id;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"payment-service/constants"
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/google/uuid"
+)
+
+// Refund is stored as pending before the gateway is called, a refund only
+// counts as refunded once its status is succeeded.
+type Refund struct {
+	ID        uint                   `gorm:"primaryKey;autoIncrement"`
+	UUID      uuid.UUID              `gorm:"type:uuid;not null"`
+	PaymentID uint                   `gorm:"type:bigint;not null;index"`
+	RefundKey string                 `gorm:"type:varchar(255);not null;uniqueIndex"`
+	Amount    money.Money            `gorm:"embedded"`
+	Reason    string                 `gorm:"type:text;not null"`
+	Status    constants.RefundStatus `gorm:"type:varchar(30);not null;default:'pending';index"`
+	Error     *string                `gorm:"type:text"`
+	CreatedAt *time.Time
+	UpdatedAt *time.Time
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"payment-service/constants"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookNotification struct {
+	ID                uint                                `gorm:"primaryKey;autoIncrement"`
+	DedupeKey         string                              `gorm:"type:varchar(255);not null;uniqueIndex"`
+	Gateway           constants.PaymentGateway            `gorm:"type:varchar(30);not null;default:'midtrans'"`
+	OrderID           uuid.UUID                           `gorm:"type:uuid;not null;index"`
+	TransactionID     string                              `gorm:"type:varchar(255);not null"`
+	TransactionStatus constants.PaymentStatusString       `gorm:"type:varchar(30);not null"`
+	Payload           string                              `gorm:"type:text;not null"`
+	Status            constants.WebhookNotificationStatus `gorm:"type:varchar(30);not null"`
+	Error             *string                             `gorm:"type:text;default: null"`
+	Attempts          int                                 `gorm:"not null;default:0"`
+	ProcessedAt       *time.Time
+	CreatedAt         *time.Time
+	UpdatedAt         *time.Time
+}
@@ -0,0 +1,621 @@
+// Package midtranssim is a fake Midtrans for local development and
+// integration tests. It serves the Snap and Core API endpoints the payment
+// service calls, keeps the transactions in memory and sends signed HTTP
+// notifications the way Midtrans does, so the whole payment flow can run
+// without the sandbox.
+//
+// In tests, wrap it with httptest.NewServer and point the Midtrans client at
+// the test server URL. For local demos, run `go run . midtrans-simulator`.
+package midtranssim
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/midtrans/midtrans-go/coreapi"
+	"github.com/midtrans/midtrans-go/snap"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StatusPending       = "pending"
+	StatusSettlement    = "settlement"
+	StatusCapture       = "capture"
+	StatusDeny          = "deny"
+	StatusCancel        = "cancel"
+	StatusExpire        = "expire"
+	StatusFailure       = "failure"
+	StatusRefund        = "refund"
+	StatusPartialRefund = "partial_refund"
+
+	merchantID     = "M000000"
+	timeLayout     = "2006-01-02 15:04:05"
+	notifyTimeout  = 10 * time.Second
+	notifyAttempts = 5
+)
+
+// statusCodes are the status_code values Midtrans sends with each
+// transaction status, which are part of the notification signature.
+var statusCodes = map[string]string{
+	StatusPending:       "201",
+	StatusSettlement:    "200",
+	StatusCapture:       "200",
+	StatusDeny:          "202",
+	StatusCancel:        "200",
+	StatusExpire:        "407",
+	StatusFailure:       "202",
+	StatusRefund:        "200",
+	StatusPartialRefund: "200",
+}
+
+// Transaction is a transaction as the simulator keeps it. Status stays empty
+// until the customer picks a payment method, like a Snap transaction that
+// Midtrans does not know yet.
+type Transaction struct {
+	OrderID        string    `json:"order_id"`
+	TransactionID  string    `json:"transaction_id"`
+	Token          string    `json:"token"`
+	GrossAmount    int64     `json:"gross_amount"`
+	RefundedAmount int64     `json:"refunded_amount"`
+	Status         string    `json:"transaction_status"`
+	PaymentType    string    `json:"payment_type"`
+	Bank           string    `json:"bank"`
+	VANumber       string    `json:"va_number,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	SettledAt      time.Time `json:"settled_at"`
+}
+
+type Server struct {
+	serverKey    string
+	webhookURL   string
+	client       *http.Client
+	mux          *http.ServeMux
+	mu           sync.Mutex
+	transactions map[string]*Transaction
+}
+
+// New creates a simulator that accepts requests signed with serverKey and
+// sends notifications to webhookURL. An empty webhookURL disables them.
+func New(serverKey, webhookURL string) *Server {
+	s := &Server{
+		serverKey:    serverKey,
+		webhookURL:   webhookURL,
+		client:       &http.Client{Timeout: notifyTimeout},
+		mux:          http.NewServeMux(),
+		transactions: make(map[string]*Transaction),
+	}
+
+	s.mux.HandleFunc("POST /snap/v1/transactions", s.createSnapTransaction)
+	s.mux.HandleFunc("GET /snap/v4/redirection/{token}", s.redirection)
+	s.mux.HandleFunc("POST /v2/charge", s.charge)
+	s.mux.HandleFunc("GET /v2/{orderID}/status", s.getStatus)
+	s.mux.HandleFunc("POST /v2/{orderID}/cancel", s.cancel)
+	s.mux.HandleFunc("POST /v2/{orderID}/expire", s.expire)
+	s.mux.HandleFunc("POST /v2/{orderID}/refund", s.refund)
+	s.mux.HandleFunc("GET /simulator/transactions", s.listTransactions)
+	s.mux.HandleFunc("POST /simulator/transactions/{orderID}/notify", s.notify)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Transaction returns a copy of the transaction of the order.
+func (s *Server) Transaction(orderID string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.transactions[orderID]
+	if !ok {
+		return Transaction{}, false
+	}
+
+	return *transaction, true
+}
+
+// Notify moves the transaction to status and sends the notification
+// synchronously, returning the HTTP status the webhook answered with.
+// paymentType defaults to bank_transfer.
+func (s *Server) Notify(orderID, status, paymentType string) (int, error) {
+	if _, ok := statusCodes[status]; !ok {
+		return 0, fmt.Errorf("unknown transaction status %q", status)
+	}
+
+	if paymentType == "" {
+		paymentType = "bank_transfer"
+	}
+
+	s.mu.Lock()
+	transaction, ok := s.transactions[orderID]
+	if !ok {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("transaction %s not found", orderID)
+	}
+
+	transaction.PaymentType = paymentType
+	s.setStatus(transaction, status)
+	payload := s.notification(transaction)
+	s.mu.Unlock()
+
+	return s.send(payload)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	username, _, ok := r.BasicAuth()
+	return ok && username == s.serverKey
+}
+
+func (s *Server) setStatus(transaction *Transaction, status string) {
+	now := time.Now()
+	transaction.Status = status
+	transaction.UpdatedAt = now
+	if status == StatusSettlement || status == StatusCapture {
+		transaction.SettledAt = now
+	}
+}
+
+func (s *Server) createSnapTransaction(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{
+			"error_messages": []string{"Access denied due to unauthorized transaction, please check client or server key"},
+		})
+		return
+	}
+
+	var req snap.Request
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error_messages": []string{err.Error()}})
+		return
+	}
+
+	errorMessages := make([]string, 0)
+	if req.TransactionDetails.OrderID == "" {
+		errorMessages = append(errorMessages, "transaction_details.order_id is required")
+	}
+
+	if req.TransactionDetails.GrossAmt <= 0 {
+		errorMessages = append(errorMessages, "transaction_details.gross_amount must be greater than 0")
+	}
+
+	if req.Items != nil {
+		var itemTotal int64
+		for _, item := range *req.Items {
+			itemTotal += item.Price * int64(item.Qty)
+		}
+
+		if itemTotal != req.TransactionDetails.GrossAmt {
+			errorMessages = append(errorMessages,
+				"transaction_details.gross_amount is not equal to the sum of item_details")
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.transactions[req.TransactionDetails.OrderID]; ok {
+		errorMessages = append(errorMessages, "transaction_details.order_id has already been taken")
+	}
+
+	if len(errorMessages) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error_messages": errorMessages})
+		return
+	}
+
+	now := time.Now()
+	transaction := &Transaction{
+		OrderID:       req.TransactionDetails.OrderID,
+		TransactionID: uuid.NewString(),
+		Token:         uuid.NewString(),
+		GrossAmount:   req.TransactionDetails.GrossAmt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.transactions[transaction.OrderID] = transaction
+
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"token":        transaction.Token,
+		"redirect_url": fmt.Sprintf("http://%s/snap/v4/redirection/%s", r.Host, transaction.Token),
+	})
+}
+
+// charge answers a Core API charge for bank_transfer, echannel and qris with
+// the payment details Midtrans would return, and sends the pending
+// notification.
+func (s *Server) charge(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{
+			"status_code":    "401",
+			"status_message": "Unknown Merchant server_key/id",
+		})
+		return
+	}
+
+	var req coreapi.ChargeReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"status_code":    "400",
+			"status_message": err.Error(),
+		})
+		return
+	}
+
+	switch req.PaymentType {
+	case coreapi.PaymentTypeBankTransfer, coreapi.PaymentTypeEChannel, coreapi.PaymentTypeQris:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"status_code":    "400",
+			"status_message": fmt.Sprintf("payment_type %s is not supported by the simulator", req.PaymentType),
+		})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.transactions[req.TransactionDetails.OrderID]; ok {
+		writeJSON(w, http.StatusNotAcceptable, map[string]string{
+			"status_code":    "406",
+			"status_message": "The request could not be completed due to a conflict with the current state of the target resource, please try again",
+		})
+		return
+	}
+
+	now := time.Now()
+	transaction := &Transaction{
+		OrderID:       req.TransactionDetails.OrderID,
+		TransactionID: uuid.NewString(),
+		GrossAmount:   req.TransactionDetails.GrossAmt,
+		PaymentType:   string(req.PaymentType),
+		CreatedAt:     now,
+	}
+	if req.BankTransfer != nil {
+		transaction.Bank = string(req.BankTransfer.Bank)
+		transaction.VANumber = req.BankTransfer.VaNumber
+	}
+	s.transactions[transaction.OrderID] = transaction
+	s.setStatus(transaction, StatusPending)
+
+	payload := s.notification(transaction)
+	payload["status_message"] = "Success, transaction is created"
+	if transaction.PaymentType == string(coreapi.PaymentTypeQris) {
+		payload["qr_string"] = fmt.Sprintf("00020101021226620014COM.GO-JEK.WWW011993600914%s", paymentNumber(transaction.OrderID))
+		payload["actions"] = []map[string]string{{
+			"name":   "generate-qr-code",
+			"method": http.MethodGet,
+			"url":    fmt.Sprintf("http://%s/v2/qris/%s/qr-code", r.Host, transaction.TransactionID),
+		}}
+	}
+	writeJSON(w, http.StatusOK, payload)
+
+	go s.sendAsync(s.notification(transaction))
+}
+
+// redirection stands in for the Snap payment page and explains how to pay.
+func (s *Server) redirection(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, transaction := range s.transactions {
+		if transaction.Token != r.PathValue("token") {
+			continue
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "Midtrans simulator\n\norder id: %s\namount: %d\nstatus: %s\n\n"+
+			"Pay with:\ncurl -X POST http://%s/simulator/transactions/%s/notify "+
+			"-d '{\"transaction_status\":\"settlement\",\"payment_type\":\"bank_transfer\"}'\n",
+			transaction.OrderID, transaction.GrossAmount, transaction.Status, r.Host, transaction.OrderID)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// coreTransaction looks up the transaction for a Core API request and writes
+// the Midtrans error itself when there is none.
+func (s *Server) coreTransaction(w http.ResponseWriter, r *http.Request) (*Transaction, bool) {
+	if !s.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{
+			"status_code":    "401",
+			"status_message": "Unknown Merchant server_key/id",
+		})
+		return nil, false
+	}
+
+	transaction, ok := s.transactions[r.PathValue("orderID")]
+	if !ok || transaction.Status == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{
+			"status_code":    "404",
+			"status_message": "Transaction doesn't exist.",
+		})
+		return nil, false
+	}
+
+	return transaction, true
+}
+
+func (s *Server) getStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.coreTransaction(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.notification(transaction))
+}
+
+func (s *Server) cancel(w http.ResponseWriter, r *http.Request) {
+	s.close(w, r, StatusCancel)
+}
+
+func (s *Server) expire(w http.ResponseWriter, r *http.Request) {
+	s.close(w, r, StatusExpire)
+}
+
+func (s *Server) close(w http.ResponseWriter, r *http.Request, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.coreTransaction(w, r)
+	if !ok {
+		return
+	}
+
+	if transaction.Status != StatusPending {
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{
+			"status_code":    "412",
+			"status_message": "Merchant cannot modify the status of the transaction",
+		})
+		return
+	}
+
+	s.setStatus(transaction, status)
+	payload := s.notification(transaction)
+	writeJSON(w, http.StatusOK, payload)
+
+	// The payment service calls us while it holds the payment row, so the
+	// notification must not be waited for here.
+	go s.sendAsync(payload)
+}
+
+func (s *Server) refund(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.coreTransaction(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		RefundKey string `json:"refund_key"`
+		Amount    int64  `json:"amount"`
+		Reason    string `json:"reason"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"status_code":    "400",
+			"status_message": err.Error(),
+		})
+		return
+	}
+
+	if transaction.Status != StatusSettlement && transaction.Status != StatusPartialRefund {
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{
+			"status_code":    "412",
+			"status_message": "Transaction status cannot be updated.",
+		})
+		return
+	}
+
+	refundable := transaction.GrossAmount - transaction.RefundedAmount
+	if req.Amount == 0 {
+		req.Amount = refundable
+	}
+
+	if req.Amount < 0 || req.Amount > refundable {
+		writeJSON(w, http.StatusPreconditionFailed, map[string]string{
+			"status_code":    "412",
+			"status_message": "Refund amount exceeds the refundable amount.",
+		})
+		return
+	}
+
+	transaction.RefundedAmount += req.Amount
+	status := StatusPartialRefund
+	if transaction.RefundedAmount == transaction.GrossAmount {
+		status = StatusRefund
+	}
+	s.setStatus(transaction, status)
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status_code":        "200",
+		"status_message":     "Success, refund request is approved",
+		"transaction_id":     transaction.TransactionID,
+		"order_id":           transaction.OrderID,
+		"gross_amount":       formatAmount(transaction.GrossAmount),
+		"currency":           "IDR",
+		"payment_type":       transaction.PaymentType,
+		"transaction_time":   transaction.CreatedAt.Format(timeLayout),
+		"transaction_status": status,
+		"refund_amount":      formatAmount(req.Amount),
+		"refund_key":         req.RefundKey,
+	})
+
+	go s.sendAsync(s.notification(transaction))
+}
+
+func (s *Server) listTransactions(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transactions := make([]Transaction, 0, len(s.transactions))
+	for _, transaction := range s.transactions {
+		transactions = append(transactions, *transaction)
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.Before(transactions[j].CreatedAt)
+	})
+
+	writeJSON(w, http.StatusOK, transactions)
+}
+
+func (s *Server) notify(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TransactionStatus string `json:"transaction_status"`
+		PaymentType       string `json:"payment_type"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	webhookStatus, err := s.Notify(r.PathValue("orderID"), req.TransactionStatus, req.PaymentType)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"webhookStatus": webhookStatus})
+}
+
+// notification builds the notification body, which is also what the status
+// endpoint returns, with the payment method fields of its payment type.
+func (s *Server) notification(transaction *Transaction) map[string]any {
+	statusCode := statusCodes[transaction.Status]
+	grossAmount := formatAmount(transaction.GrossAmount)
+	payload := map[string]any{
+		"transaction_time":   transaction.CreatedAt.Format(timeLayout),
+		"transaction_status": transaction.Status,
+		"transaction_id":     transaction.TransactionID,
+		"status_message":     "midtrans payment notification",
+		"status_code":        statusCode,
+		"signature_key":      s.signature(transaction.OrderID, statusCode, grossAmount),
+		"payment_type":       transaction.PaymentType,
+		"order_id":           transaction.OrderID,
+		"merchant_id":        merchantID,
+		"gross_amount":       grossAmount,
+		"fraud_status":       "accept",
+		"currency":           "IDR",
+	}
+
+	if !transaction.SettledAt.IsZero() {
+		payload["settlement_time"] = transaction.SettledAt.Format(timeLayout)
+	}
+
+	number := paymentNumber(transaction.OrderID)
+	if transaction.VANumber != "" {
+		number = transaction.VANumber
+	}
+
+	switch transaction.PaymentType {
+	case "bank_transfer":
+		bank := transaction.Bank
+		if bank == "" {
+			bank = "bca"
+		}
+
+		if bank == "permata" {
+			payload["permata_va_number"] = number
+		} else {
+			payload["va_numbers"] = []map[string]string{{"bank": bank, "va_number": number}}
+		}
+	case "echannel":
+		payload["bill_key"] = number
+		payload["biller_code"] = "70012"
+	case "credit_card":
+		payload["bank"] = "bni"
+		payload["masked_card"] = "481111-1114"
+		payload["card_type"] = "credit"
+		payload["approval_code"] = "1234567"
+	case "cstore":
+		payload["store"] = "alfamart"
+		payload["payment_code"] = number
+	case "gopay", "shopeepay", "qris":
+		payload["issuer"] = transaction.PaymentType
+		payload["acquirer"] = transaction.PaymentType
+	}
+
+	return payload
+}
+
+func (s *Server) signature(orderID, statusCode, grossAmount string) string {
+	hash := sha512.Sum512([]byte(orderID + statusCode + grossAmount + s.serverKey))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *Server) send(payload map[string]any) (int, error) {
+	if s.webhookURL == "" {
+		return 0, nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := s.client.Post(s.webhookURL, "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode, nil
+}
+
+// sendAsync retries a failed notification a few times with a growing delay,
+// like Midtrans does, which also covers notifications that arrive before the
+// payment service committed the payment.
+func (s *Server) sendAsync(payload map[string]any) {
+	for attempt := 1; attempt <= notifyAttempts; attempt++ {
+		statusCode, err := s.send(payload)
+		if err == nil && statusCode < http.StatusInternalServerError {
+			logrus.Infof("midtranssim: notified order %s (%s): %d",
+				payload["order_id"], payload["transaction_status"], statusCode)
+			return
+		}
+
+		logrus.Warnf("midtranssim: failed to notify order %s (attempt %d): %d %v",
+			payload["order_id"], attempt, statusCode, err)
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+}
+
+// paymentNumber derives a stable VA number or payment code from the order id.
+func paymentNumber(orderID string) string {
+	hash := sha512.Sum512([]byte(orderID))
+	number := make([]byte, 0, 11)
+	for _, b := range hash[:11] {
+		number = append(number, '0'+b%10)
+	}
+
+	return "12345" + string(number)
+}
+
+func formatAmount(amount int64) string {
+	return fmt.Sprintf("%d.00", amount)
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
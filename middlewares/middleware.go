@@ -16,6 +16,7 @@ import (
 	"github.com/didip/tollbooth"
 	"github.com/didip/tollbooth/limiter"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -35,6 +36,23 @@ func HandlePanic() gin.HandlerFunc {
 	}
 }
 
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(constants.XRequestID)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+
+		// The handlers pass the *gin.Context on as the context, which only
+		// looks up its own keys, so the ID is set there as well as on the
+		// request context.
+		c.Set(constants.RequestID, requestID)
+		c.Writer.Header().Set(constants.XRequestID, requestID)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), constants.RequestID, requestID))
+		c.Next()
+	}
+}
+
 func RateLimiter(lmt *limiter.Limiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		err := tollbooth.LimitByRequest(lmt, c.Writer, c.Request)
@@ -0,0 +1,12 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// Run applies the schema changes AutoMigrate can't make on its own, such as
+// converting existing data. It runs before AutoMigrate, every migration
+// checks the schema first so running it again is a no-op.
+func Run(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return moneyMinorUnits(tx)
+	})
+}
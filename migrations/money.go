@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"strings"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type minorUnitColumn struct {
+	table  string
+	column string
+}
+
+var minorUnitColumns = []minorUnitColumn{
+	{table: "payments", column: "amount"},
+}
+
+// moneyMinorUnits converts the amounts stored as decimal rupiah into bigint
+// minor units. Left to AutoMigrate the column type would change without
+// multiplying, turning Rp 10.000 into Rp 100. The currency columns are added
+// by AutoMigrate with IDR as the default.
+func moneyMinorUnits(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	for _, c := range minorUnitColumns {
+		if !migrator.HasTable(c.table) {
+			continue
+		}
+
+		columnTypes, err := migrator.ColumnTypes(c.table)
+		if err != nil {
+			return err
+		}
+
+		for _, columnType := range columnTypes {
+			if columnType.Name() != c.column || isInteger(columnType.DatabaseTypeName()) {
+				continue
+			}
+
+			logrus.Infof("converting %s.%s to minor units", c.table, c.column)
+			err = tx.Exec("ALTER TABLE ? ALTER COLUMN ? TYPE bigint USING ROUND(?::numeric * ?)",
+				clause.Table{Name: c.table},
+				clause.Column{Name: c.column},
+				clause.Column{Name: c.column},
+				minorUnits(),
+			).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func isInteger(databaseType string) bool {
+	switch strings.ToLower(databaseType) {
+	case "int8", "bigint", "int4", "integer":
+		return true
+	default:
+		return false
+	}
+}
+
+// minorUnits is the number of minor units in one major unit.
+func minorUnits() int64 {
+	return money.FromMajor(1, money.DefaultCurrency).Amount
+}
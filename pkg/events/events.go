@@ -0,0 +1,92 @@
+// Package events defines the contract of the events payment-service
+// publishes to Kafka. Consumers can depend on these types and on the JSON
+// Schema files under schemas/ instead of re-declaring the payload.
+package events
+
+import (
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/google/uuid"
+)
+
+// SchemaVersion is bumped whenever a published event changes in a way that
+// is not backwards compatible.
+const SchemaVersion = "1"
+
+const (
+	Sender   = "payment-service"
+	BodyType = "JSON"
+)
+
+// Event names. PaymentSettled keeps the SETTLEMENT name consumers received
+// before the contract was versioned.
+const (
+	PaymentCreated        = "PAYMENT_CREATED"
+	PaymentPending        = "PENDING"
+	PaymentSettled        = "SETTLEMENT"
+	PaymentExpired        = "EXPIRED"
+	PaymentRefunded       = "REFUNDED"
+	PaymentCancelled      = "CANCELLED"
+	PaymentFailed         = "FAILED"
+	PaymentAmountMismatch = "AMOUNT_MISMATCH"
+)
+
+type Event struct {
+	Name string `json:"name"`
+}
+
+type Metadata struct {
+	Sender        string `json:"sender"`
+	SendingAt     string `json:"sendingAt"`
+	SchemaVersion string `json:"schemaVersion"`
+}
+
+// PaymentData is the payment an event is about. A REFUNDED event also
+// carries the amount of the refund and the total refunded so far, Amount is
+// always the amount of the payment.
+type PaymentData struct {
+	OrderID        uuid.UUID    `json:"orderID"`
+	PaymentID      uuid.UUID    `json:"paymentID"`
+	Status         string       `json:"status"`
+	Amount         money.Money  `json:"amount"`
+	Currency       string       `json:"currency,omitempty"`
+	RefundAmount   *money.Money `json:"refundAmount,omitempty"`
+	RefundedAmount *money.Money `json:"refundedAmount,omitempty"`
+	PaymentLink    string       `json:"paymentLink,omitempty"`
+	PaymentType    string       `json:"paymentType,omitempty"`
+	Bank           string       `json:"bank,omitempty"`
+	VANumber       string       `json:"vaNumber,omitempty"`
+	BillerCode     string       `json:"billerCode,omitempty"`
+	QRString       string       `json:"qrString,omitempty"`
+	PaidAt         *time.Time   `json:"paidAt"`
+	ExpiredAt      time.Time    `json:"expiredAt"`
+}
+
+type Body struct {
+	Type string       `json:"type"`
+	Data *PaymentData `json:"data"`
+}
+
+type Envelope struct {
+	Event    Event    `json:"event"`
+	Metadata Metadata `json:"metadata"`
+	Body     Body     `json:"body"`
+}
+
+func NewEnvelope(name string, data *PaymentData, sendingAt time.Time) *Envelope {
+	return &Envelope{
+		Event: Event{
+			Name: name,
+		},
+		Metadata: Metadata{
+			Sender:        Sender,
+			SendingAt:     sendingAt.Format(time.RFC3339),
+			SchemaVersion: SchemaVersion,
+		},
+		Body: Body{
+			Type: BodyType,
+			Data: data,
+		},
+	}
+}
@@ -0,0 +1,225 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/google/uuid"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files under testdata/")
+
+var (
+	sendingAt = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	paidAt    = time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	expiredAt = time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+)
+
+// samples are the payloads the service publishes for each event, the golden
+// files under testdata/ pin their JSON.
+var samples = map[string]func(*PaymentData){
+	PaymentCreated: func(data *PaymentData) {
+		data.Status = "initial"
+		data.PaymentLink = "https://app.sandbox.midtrans.com/snap/v4/redirection/4a1b2c3d"
+		data.PaidAt = nil
+	},
+	PaymentPending: func(data *PaymentData) {
+		data.Status = "pending"
+		data.PaymentType = "bank_transfer"
+		data.Bank = "bca"
+		data.VANumber = "12345678901"
+		data.PaidAt = nil
+	},
+	PaymentSettled: func(data *PaymentData) {
+		data.Status = "settlement"
+		data.PaymentType = "bank_transfer"
+		data.Bank = "bca"
+		data.VANumber = "12345678901"
+	},
+	PaymentExpired: func(data *PaymentData) {
+		data.Status = "expired"
+		data.PaidAt = nil
+	},
+	PaymentRefunded: func(data *PaymentData) {
+		refundAmount := money.FromMajor(40000, money.IDR)
+		refundedAmount := money.FromMajor(60000, money.IDR)
+		data.Status = "partial_refund"
+		data.RefundAmount = &refundAmount
+		data.RefundedAmount = &refundedAmount
+	},
+	PaymentCancelled: func(data *PaymentData) {
+		data.Status = "cancel"
+		data.PaidAt = nil
+	},
+	PaymentFailed: func(data *PaymentData) {
+		data.Status = "failure"
+		data.PaidAt = nil
+	},
+	PaymentAmountMismatch: func(data *PaymentData) {
+		data.Status = "amount_mismatch"
+		data.QRString = "00020101021226620014COM.GO-JEK.WWW"
+		data.PaidAt = nil
+	},
+}
+
+func sampleEnvelope(name string) *Envelope {
+	data := &PaymentData{
+		OrderID:   uuid.MustParse("1f0e8d1c-7b2a-4c3d-9e8f-0a1b2c3d4e5f"),
+		PaymentID: uuid.MustParse("6a7b8c9d-0e1f-4a2b-8c3d-4e5f6a7b8c9d"),
+		Amount:    money.New(10000050, money.IDR),
+		Currency:  money.IDR,
+		PaidAt:    &paidAt,
+		ExpiredAt: expiredAt,
+	}
+	samples[name](data)
+
+	return NewEnvelope(name, data, sendingAt)
+}
+
+func compileSchema(t *testing.T, name string) *jsonschema.Schema {
+	t.Helper()
+
+	document, err := Schema(name)
+	if err != nil {
+		t.Fatalf("Schema(%s) error = %v", name, err)
+	}
+
+	schema, err := jsonschema.UnmarshalJSON(bytes.NewReader(document))
+	if err != nil {
+		t.Fatalf("decode schema of %s: %v", name, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+	url := fmt.Sprintf("https://payment-service/events/v%s/%s.json", SchemaVersion, name)
+	err = compiler.AddResource(url, schema)
+	if err != nil {
+		t.Fatalf("add schema of %s: %v", name, err)
+	}
+
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		t.Fatalf("compile schema of %s: %v", name, err)
+	}
+
+	return compiled
+}
+
+func validateJSON(schema *jsonschema.Schema, payload []byte) error {
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	return schema.Validate(instance)
+}
+
+func TestEventsMatchGoldenFilesAndSchemas(t *testing.T) {
+	if len(samples) != len(requirements) {
+		t.Fatalf("%d samples for %d events, add a sample for every event", len(samples), len(requirements))
+	}
+
+	for name := range requirements {
+		t.Run(name, func(t *testing.T) {
+			envelope := sampleEnvelope(name)
+			err := envelope.Validate()
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			payload, err := json.MarshalIndent(envelope, "", "  ")
+			if err != nil {
+				t.Fatalf("marshal %s: %v", name, err)
+			}
+			payload = append(payload, '\n')
+
+			golden := filepath.Join("testdata", "v"+SchemaVersion,
+				strings.ReplaceAll(strings.ToLower(name), "_", "-")+".json")
+			if *update {
+				err = os.WriteFile(golden, payload, 0o644)
+				if err != nil {
+					t.Fatalf("write %s: %v", golden, err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read %s: %v, run go test -update to create it", golden, err)
+			}
+
+			if !bytes.Equal(payload, want) {
+				t.Errorf("%s changed, a breaking change needs a new SchemaVersion:\ngot:\n%s\nwant:\n%s",
+					golden, payload, want)
+			}
+
+			err = validateJSON(compileSchema(t, name), want)
+			if err != nil {
+				t.Errorf("%s does not match its schema: %v", golden, err)
+			}
+		})
+	}
+}
+
+func TestSchemasRejectInvalidEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		event  string
+		mutate func(*Envelope)
+	}{
+		{
+			name:   "other event name",
+			event:  PaymentSettled,
+			mutate: func(e *Envelope) { e.Event.Name = PaymentPending },
+		},
+		{
+			name:   "other schema version",
+			event:  PaymentPending,
+			mutate: func(e *Envelope) { e.Metadata.SchemaVersion = "2" },
+		},
+		{
+			name:   "settled without paidAt",
+			event:  PaymentSettled,
+			mutate: func(e *Envelope) { e.Body.Data.PaidAt = nil },
+		},
+		{
+			name:  "created without payment instructions",
+			event: PaymentCreated,
+			mutate: func(e *Envelope) {
+				e.Body.Data.PaymentLink = ""
+			},
+		},
+		{
+			name:  "lower case currency",
+			event: PaymentRefunded,
+			mutate: func(e *Envelope) {
+				e.Body.Data.Currency = "idr"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope := sampleEnvelope(tt.event)
+			tt.mutate(envelope)
+
+			payload, err := json.Marshal(envelope)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			err = validateJSON(compileSchema(t, tt.event), payload)
+			if err == nil {
+				t.Errorf("schema of %s accepted %s", tt.event, payload)
+			}
+		})
+	}
+}
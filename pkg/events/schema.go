@@ -0,0 +1,21 @@
+package events
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+)
+
+//go:embed schemas
+var schemas embed.FS
+
+// Schema returns the JSON Schema document of an event for the current
+// schema version.
+func Schema(name string) ([]byte, error) {
+	if _, ok := requirements[name]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEvent, name)
+	}
+
+	filename := strings.ReplaceAll(strings.ToLower(name), "_", "-")
+	return schemas.ReadFile(fmt.Sprintf("schemas/v%s/%s.json", SchemaVersion, filename))
+}
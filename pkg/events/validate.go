@@ -0,0 +1,85 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownEvent   = errors.New("unknown event name")
+	ErrSchemaVersion  = errors.New("unsupported schema version")
+	ErrInvalidPayload = errors.New("invalid event payload")
+)
+
+type requirement func(*PaymentData) error
+
+// requirements lists the event specific rules on top of the fields every
+// payment event carries, mirroring the required fields in schemas/.
+var requirements = map[string][]requirement{
+	PaymentCreated:        {requirePaymentInstructions},
+	PaymentPending:        nil,
+	PaymentSettled:        {requirePaidAt},
+	PaymentExpired:        nil,
+	PaymentRefunded:       nil,
+	PaymentCancelled:      nil,
+	PaymentFailed:         nil,
+	PaymentAmountMismatch: nil,
+}
+
+// requirePaymentInstructions needs a way for the customer to pay: the
+// payment page, or for a direct charge the VA number or QR string.
+func requirePaymentInstructions(data *PaymentData) error {
+	if data.PaymentLink == "" && data.VANumber == "" && data.QRString == "" {
+		return fmt.Errorf("%w: paymentLink, vaNumber or qrString is required", ErrInvalidPayload)
+	}
+
+	return nil
+}
+
+func requirePaidAt(data *PaymentData) error {
+	if data.PaidAt == nil {
+		return fmt.Errorf("%w: paidAt is required", ErrInvalidPayload)
+	}
+
+	return nil
+}
+
+// Validate checks the envelope against the contract of its event name.
+func (e *Envelope) Validate() error {
+	rules, ok := requirements[e.Event.Name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownEvent, e.Event.Name)
+	}
+
+	if e.Metadata.SchemaVersion != SchemaVersion {
+		return fmt.Errorf("%w: %q", ErrSchemaVersion, e.Metadata.SchemaVersion)
+	}
+
+	if e.Metadata.Sender == "" || e.Metadata.SendingAt == "" {
+		return fmt.Errorf("%w: sender and sendingAt are required", ErrInvalidPayload)
+	}
+
+	if e.Body.Type != BodyType || e.Body.Data == nil {
+		return fmt.Errorf("%w: body must be %s data", ErrInvalidPayload, BodyType)
+	}
+
+	data := e.Body.Data
+	if data.OrderID == uuid.Nil || data.PaymentID == uuid.Nil {
+		return fmt.Errorf("%w: orderID and paymentID are required", ErrInvalidPayload)
+	}
+
+	if data.Status == "" || data.ExpiredAt.IsZero() {
+		return fmt.Errorf("%w: status and expiredAt are required", ErrInvalidPayload)
+	}
+
+	for _, rule := range rules {
+		err := rule(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
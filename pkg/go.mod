@@ -0,0 +1,10 @@
+module github.com/FaisalABR/payment-service/pkg
+
+go 1.23.2
+
+require (
+	github.com/google/uuid v1.6.0
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2
+)
+
+require golang.org/x/text v0.14.0 // indirect
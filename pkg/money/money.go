@@ -0,0 +1,185 @@
+// Package money holds amounts as integer minor units of an ISO 4217
+// currency, so they add up and compare exactly where float64 would drift.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const IDR = "IDR"
+
+// DefaultCurrency is the currency of amounts read without one, e.g. the
+// amount of a payment request.
+const DefaultCurrency = IDR
+
+// Exponent is the number of minor unit digits. Every currency the gateways
+// settle in (IDR, USD, SGD, PHP) has two under ISO 4217, even though Midtrans
+// only charges whole rupiah.
+const Exponent = 2
+
+const scale = 100
+
+// maxLength bounds the input of Parse, big.Rat would otherwise expand
+// an exponent such as 1e999999 in full.
+const maxLength = 64
+
+var (
+	ErrInvalidAmount = errors.New("invalid money amount")
+	ErrOverflow      = errors.New("money amount out of range")
+)
+
+// Money is stored as two columns, embed it with `gorm:"embedded"`.
+type Money struct {
+	Amount   int64  `gorm:"type:bigint;not null"`
+	Currency string `gorm:"type:varchar(3);not null;default:'IDR'"`
+}
+
+// New returns an amount of minor units, e.g. New(1050, IDR) is Rp 10,50.
+func New(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: currency}
+}
+
+// FromMajor returns an amount of whole units, e.g. FromMajor(10, IDR) is Rp 10.
+func FromMajor(major int64, currency string) Money {
+	return New(major*scale, currency)
+}
+
+// Parse reads a decimal such as "10000", "10000.50" or "1e4" exactly. More
+// decimals than the currency has are an error rather than rounded away.
+func Parse(value string, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > maxLength || strings.Contains(value, "/") {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	rat.Mul(rat, big.NewRat(scale, 1))
+	if !rat.IsInt() {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimals", ErrInvalidAmount, value, Exponent)
+	}
+
+	if !rat.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, value)
+	}
+
+	return New(rat.Num().Int64(), currency), nil
+}
+
+// Major rounds to whole units, halves away from zero, which is what Midtrans
+// charges.
+func (m Money) Major() int64 {
+	quotient, remainder := m.Amount/scale, m.Amount%scale
+	if remainder >= scale/2 {
+		quotient++
+	} else if remainder <= -scale/2 {
+		quotient--
+	}
+
+	return quotient
+}
+
+// Round drops the minor units, see Major.
+func (m Money) Round() Money {
+	return FromMajor(m.Major(), m.Currency)
+}
+
+// Add returns ErrOverflow instead of wrapping around when the sum doesn't fit
+// in an int64, as do Sub and Mul.
+func (m Money) Add(other Money) (Money, error) {
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m.Decimal(), other.Decimal())
+	}
+
+	return New(sum, m.currency(other)), nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	difference := m.Amount - other.Amount
+	if (other.Amount > 0 && difference > m.Amount) || (other.Amount < 0 && difference < m.Amount) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m.Decimal(), other.Decimal())
+	}
+
+	return New(difference, m.currency(other)), nil
+}
+
+func (m Money) Mul(quantity int64) (Money, error) {
+	product := m.Amount * quantity
+	if m.Amount != 0 && (product/m.Amount != quantity ||
+		(m.Amount == -1 && quantity == math.MinInt64) ||
+		(quantity == -1 && m.Amount == math.MinInt64)) {
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m.Decimal(), quantity)
+	}
+
+	return New(product, m.Currency), nil
+}
+
+func (m Money) Neg() Money {
+	return New(-m.Amount, m.Currency)
+}
+
+// currency lets a zero value without a currency take part in sums.
+func (m Money) currency(other Money) string {
+	if m.Currency == "" {
+		return other.Currency
+	}
+
+	return m.Currency
+}
+
+// Decimal formats the amount in major units, with the minor units only when
+// there are any: "10000" or "10000.5".
+func (m Money) Decimal() string {
+	sign := ""
+	amount := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		amount = uint64(-(m.Amount + 1)) + 1
+	}
+
+	major := strconv.FormatUint(amount/scale, 10)
+	minor := amount % scale
+	if minor == 0 {
+		return sign + major
+	}
+
+	fraction := strings.TrimRight(fmt.Sprintf("%0*d", Exponent, minor), "0")
+	return sign + major + "." + fraction
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%s %s", m.Currency, m.Decimal())
+}
+
+// MarshalJSON writes the amount as a number in major units, the format the
+// API and events used before amounts were exact. The currency is a separate
+// field of the enclosing object.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON reads a number or a numeric string in major units in the
+// default currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+
+	parsed, err := Parse(strings.Trim(value, `"`), DefaultCurrency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,239 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+var quickConfig = &quick.Config{MaxCount: 10000}
+
+// safe keeps an amount far enough from the int64 limits that rounding it to
+// whole units can't overflow.
+func safe(amount int64) int64 {
+	return amount / scale * (scale - 1)
+}
+
+func TestParseRoundTripsDecimal(t *testing.T) {
+	property := func(amount int64) bool {
+		parsed, err := Parse(New(amount, IDR).Decimal(), IDR)
+		return err == nil && parsed == New(amount, IDR)
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJSONRoundTrips(t *testing.T) {
+	property := func(amount int64) bool {
+		data, err := New(amount, DefaultCurrency).MarshalJSON()
+		if err != nil {
+			return false
+		}
+
+		var decoded Money
+		err = decoded.UnmarshalJSON(data)
+		return err == nil && decoded == New(amount, DefaultCurrency)
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseRejectsExtraDecimals(t *testing.T) {
+	property := func(major int32, minor uint8, extra uint8) bool {
+		value := fmt.Sprintf("%d.%02d%d", major, minor%scale, extra%9+1)
+		_, err := Parse(value, IDR)
+		return errors.Is(err, ErrInvalidAmount)
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseMatchesBigRat(t *testing.T) {
+	property := func(major int64, minor uint8) bool {
+		value := fmt.Sprintf("%d.%02d", major, minor%scale)
+		parsed, err := Parse(value, IDR)
+
+		want, _ := new(big.Rat).SetString(value)
+		want.Mul(want, big.NewRat(scale, 1))
+		if !want.Num().IsInt64() {
+			return errors.Is(err, ErrOverflow)
+		}
+
+		return err == nil && parsed.Amount == want.Num().Int64()
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMajorRoundsHalfAwayFromZero(t *testing.T) {
+	property := func(amount int64) bool {
+		amount = safe(amount)
+		major := New(amount, IDR).Major()
+		difference := amount - major*scale
+
+		if amount >= 0 && (difference < -scale/2 || difference >= scale/2) {
+			return false
+		}
+
+		if amount < 0 && (difference <= -scale/2 || difference > scale/2) {
+			return false
+		}
+
+		return New(-amount, IDR).Major() == -major
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMajorIsMonotonic(t *testing.T) {
+	property := func(a, b int64) bool {
+		a, b = safe(a), safe(b)
+		if a > b {
+			a, b = b, a
+		}
+
+		return New(a, IDR).Major() <= New(b, IDR).Major()
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRoundIsIdempotent(t *testing.T) {
+	property := func(amount int64) bool {
+		rounded := New(safe(amount), IDR).Round()
+		return rounded.Round() == rounded && rounded.Amount%scale == 0
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// checkArithmetic compares a result with the exact one from big.Int: it must
+// be an ErrOverflow exactly when the exact result doesn't fit in an int64.
+func checkArithmetic(got Money, err error, want *big.Int) bool {
+	if !want.IsInt64() {
+		return errors.Is(err, ErrOverflow)
+	}
+
+	return err == nil && got.Amount == want.Int64()
+}
+
+func TestAddMatchesBigInt(t *testing.T) {
+	property := func(a, b int64) bool {
+		got, err := New(a, IDR).Add(New(b, IDR))
+		return checkArithmetic(got, err, new(big.Int).Add(big.NewInt(a), big.NewInt(b)))
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubMatchesBigInt(t *testing.T) {
+	property := func(a, b int64) bool {
+		got, err := New(a, IDR).Sub(New(b, IDR))
+		return checkArithmetic(got, err, new(big.Int).Sub(big.NewInt(a), big.NewInt(b)))
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMulMatchesBigInt(t *testing.T) {
+	property := func(a int64, quantity int64, small int16) bool {
+		// Uniform int64 pairs nearly always overflow, a small quantity covers
+		// the products that fit as well.
+		for _, q := range []int64{quantity, int64(small)} {
+			got, err := New(a, IDR).Mul(q)
+			if !checkArithmetic(got, err, new(big.Int).Mul(big.NewInt(a), big.NewInt(q))) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	err := quick.Check(property, quickConfig)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestArithmeticOverflowEdges(t *testing.T) {
+	tests := []struct {
+		name string
+		got  func() (Money, error)
+		want *big.Int
+	}{
+		{
+			name: "max plus one",
+			got:  func() (Money, error) { return New(math.MaxInt64, IDR).Add(New(1, IDR)) },
+			want: new(big.Int).Add(big.NewInt(math.MaxInt64), big.NewInt(1)),
+		},
+		{
+			name: "min minus one",
+			got:  func() (Money, error) { return New(math.MinInt64, IDR).Sub(New(1, IDR)) },
+			want: new(big.Int).Sub(big.NewInt(math.MinInt64), big.NewInt(1)),
+		},
+		{
+			name: "zero minus min",
+			got:  func() (Money, error) { return New(0, IDR).Sub(New(math.MinInt64, IDR)) },
+			want: new(big.Int).Neg(big.NewInt(math.MinInt64)),
+		},
+		{
+			name: "min times minus one",
+			got:  func() (Money, error) { return New(math.MinInt64, IDR).Mul(-1) },
+			want: new(big.Int).Neg(big.NewInt(math.MinInt64)),
+		},
+		{
+			name: "minus one times min",
+			got:  func() (Money, error) { return New(-1, IDR).Mul(math.MinInt64) },
+			want: new(big.Int).Neg(big.NewInt(math.MinInt64)),
+		},
+		{
+			name: "max times one",
+			got:  func() (Money, error) { return New(math.MaxInt64, IDR).Mul(1) },
+			want: big.NewInt(math.MaxInt64),
+		},
+		{
+			name: "zero times min",
+			got:  func() (Money, error) { return New(0, IDR).Mul(math.MinInt64) },
+			want: big.NewInt(0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.got()
+			if !checkArithmetic(got, err, tt.want) {
+				t.Errorf("got %v, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
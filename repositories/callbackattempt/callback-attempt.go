@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	errorWrap "payment-service/common/error"
+	errConstants "payment-service/constants/error"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+
+	"gorm.io/gorm"
+)
+
+type CallbackAttemptRepository struct {
+	db *gorm.DB
+}
+
+type ICallbackAttemptRepository interface {
+	Create(context.Context, *gorm.DB, *dto.CallbackAttemptRequest) error
+}
+
+func NewCallbackAttemptRepository(db *gorm.DB) ICallbackAttemptRepository {
+	return &CallbackAttemptRepository{db: db}
+}
+
+func (c *CallbackAttemptRepository) Create(ctx context.Context, tx *gorm.DB, req *dto.CallbackAttemptRequest) error {
+	attempt := models.CallbackAttempt{
+		DeliveryID:   req.DeliveryID,
+		Attempt:      req.Attempt,
+		ResponseCode: req.ResponseCode,
+		Error:        req.Error,
+		DurationMS:   req.Duration.Milliseconds(),
+		AttemptedAt:  req.AttemptedAt,
+	}
+
+	err := tx.WithContext(ctx).
+		Create(&attempt).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
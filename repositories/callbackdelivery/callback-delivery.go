@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	errorWrap "payment-service/common/error"
+	"payment-service/constants"
+	errConstants "payment-service/constants/error"
+	errCallback "payment-service/constants/error/callback"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CallbackDeliveryRepository struct {
+	db *gorm.DB
+}
+
+type ICallbackDeliveryRepository interface {
+	FindAllWithPagination(context.Context, *dto.CallbackDeliveryRequestParam) ([]models.CallbackDelivery, int64, error)
+	FindByUUID(context.Context, string) (*models.CallbackDelivery, error)
+	FindDeliverableForUpdate(context.Context, *gorm.DB, time.Time, int) ([]models.CallbackDelivery, error)
+	Claim(context.Context, *gorm.DB, []uint, time.Time) error
+	Create(context.Context, *gorm.DB, *dto.CallbackDeliveryRequest) error
+	MarkDelivered(context.Context, *gorm.DB, uint, int, time.Time) error
+	MarkFailed(context.Context, *gorm.DB, uint, *int, string, constants.CallbackDeliveryStatus, time.Time) error
+	Redeliver(context.Context, string) (*models.CallbackDelivery, error)
+}
+
+func NewCallbackDeliveryRepository(db *gorm.DB) ICallbackDeliveryRepository {
+	return &CallbackDeliveryRepository{db: db}
+}
+
+func (c *CallbackDeliveryRepository) FindAllWithPagination(
+	ctx context.Context,
+	params *dto.CallbackDeliveryRequestParam,
+) ([]models.CallbackDelivery, int64, error) {
+	var (
+		deliveries []models.CallbackDelivery
+		total      int64
+	)
+
+	query := c.db.WithContext(ctx).Model(&models.CallbackDelivery{})
+	if params.Status != nil {
+		query = query.Where("status = ?", *params.Status)
+	}
+	if params.AggregateID != nil {
+		query = query.Where("aggregate_id = ?", *params.AggregateID)
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	limit := params.Limit
+	offset := (params.Page - 1) * params.Limit
+	err = query.
+		Preload("Registration").
+		Limit(limit).
+		Offset(offset).
+		Order("id desc").
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return deliveries, total, nil
+}
+
+func (c *CallbackDeliveryRepository) FindByUUID(ctx context.Context, uuid string) (*models.CallbackDelivery, error) {
+	var delivery models.CallbackDelivery
+
+	err := c.db.WithContext(ctx).
+		Preload("Registration").
+		Preload("History", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempt asc")
+		}).
+		Where("uuid = ?", uuid).
+		First(&delivery).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorWrap.WrapError(errCallback.ErrDeliveryNotFound)
+		}
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return &delivery, nil
+}
+
+// FindDeliverableForUpdate locks a batch of pending deliveries that are due
+// for an attempt, skipping rows another replica is already sending.
+func (c *CallbackDeliveryRepository) FindDeliverableForUpdate(
+	ctx context.Context,
+	tx *gorm.DB,
+	now time.Time,
+	limit int,
+) ([]models.CallbackDelivery, error) {
+	var deliveries []models.CallbackDelivery
+
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Preload("Registration").
+		Where("status = ?", constants.CallbackPending).
+		Where("next_attempt_at <= ?", now).
+		Order("id asc").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return deliveries, nil
+}
+
+// Claim moves the next attempt of the deliveries to until, so once the
+// claiming transaction commits no other replica picks them up while they are
+// being sent. A delivery whose sender died is picked up again after until.
+func (c *CallbackDeliveryRepository) Claim(ctx context.Context, tx *gorm.DB, ids []uint, until time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := tx.WithContext(ctx).
+		Model(&models.CallbackDelivery{}).
+		Where("id IN ?", ids).
+		Update("next_attempt_at", until).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
+
+func (c *CallbackDeliveryRepository) Create(ctx context.Context, tx *gorm.DB, req *dto.CallbackDeliveryRequest) error {
+	delivery := models.CallbackDelivery{
+		UUID:           uuid.New(),
+		RegistrationID: req.RegistrationID,
+		AggregateID:    req.AggregateID,
+		EventName:      req.EventName,
+		Payload:        req.Payload,
+		Status:         constants.CallbackPending,
+		NextAttemptAt:  time.Now(),
+	}
+
+	err := tx.WithContext(ctx).
+		Omit("Registration").
+		Create(&delivery).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
+
+func (c *CallbackDeliveryRepository) MarkDelivered(
+	ctx context.Context,
+	tx *gorm.DB,
+	id uint,
+	responseCode int,
+	deliveredAt time.Time,
+) error {
+	err := tx.WithContext(ctx).
+		Model(&models.CallbackDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":        constants.CallbackDelivered,
+			"response_code": responseCode,
+			"last_error":    nil,
+			"delivered_at":  deliveredAt,
+			"attempts":      gorm.Expr("attempts + 1"),
+		}).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
+
+func (c *CallbackDeliveryRepository) MarkFailed(
+	ctx context.Context,
+	tx *gorm.DB,
+	id uint,
+	responseCode *int,
+	lastError string,
+	status constants.CallbackDeliveryStatus,
+	nextAttemptAt time.Time,
+) error {
+	err := tx.WithContext(ctx).
+		Model(&models.CallbackDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          status,
+			"response_code":   responseCode,
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+			"attempts":        gorm.Expr("attempts + 1"),
+		}).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
+
+// Redeliver queues a delivery again for an immediate attempt with a fresh
+// retry budget, whatever state it ended up in.
+func (c *CallbackDeliveryRepository) Redeliver(ctx context.Context, uuid string) (*models.CallbackDelivery, error) {
+	result := c.db.WithContext(ctx).
+		Model(&models.CallbackDelivery{}).
+		Where("uuid = ?", uuid).
+		Updates(map[string]interface{}{
+			"status":          constants.CallbackPending,
+			"next_attempt_at": time.Now(),
+			"delivered_at":    nil,
+			"attempts":        0,
+		})
+	if result.Error != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, errorWrap.WrapError(errCallback.ErrDeliveryNotFound)
+	}
+
+	return c.FindByUUID(ctx, uuid)
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	errorWrap "payment-service/common/error"
+	errConstants "payment-service/constants/error"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CallbackRegistrationRepository struct {
+	db *gorm.DB
+}
+
+type ICallbackRegistrationRepository interface {
+	FindAll(context.Context) ([]models.CallbackRegistration, error)
+	FindActiveByEvent(context.Context, *gorm.DB, string) ([]models.CallbackRegistration, error)
+	Create(context.Context, *dto.CallbackRegistrationRequest) (*models.CallbackRegistration, error)
+}
+
+func NewCallbackRegistrationRepository(db *gorm.DB) ICallbackRegistrationRepository {
+	return &CallbackRegistrationRepository{db: db}
+}
+
+func (c *CallbackRegistrationRepository) FindAll(ctx context.Context) ([]models.CallbackRegistration, error) {
+	var registrations []models.CallbackRegistration
+
+	err := c.db.WithContext(ctx).
+		Order("id asc").
+		Find(&registrations).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return registrations, nil
+}
+
+// FindActiveByEvent returns the active registrations subscribed to the event.
+// Events are stored as a JSON array, so the subscription is matched in Go.
+func (c *CallbackRegistrationRepository) FindActiveByEvent(
+	ctx context.Context,
+	tx *gorm.DB,
+	eventName string,
+) ([]models.CallbackRegistration, error) {
+	var registrations []models.CallbackRegistration
+
+	err := tx.WithContext(ctx).
+		Where("is_active = ?", true).
+		Order("id asc").
+		Find(&registrations).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	subscribed := make([]models.CallbackRegistration, 0, len(registrations))
+	for _, registration := range registrations {
+		for _, event := range registration.Events {
+			if event == eventName {
+				subscribed = append(subscribed, registration)
+				break
+			}
+		}
+	}
+
+	return subscribed, nil
+}
+
+func (c *CallbackRegistrationRepository) Create(
+	ctx context.Context,
+	req *dto.CallbackRegistrationRequest,
+) (*models.CallbackRegistration, error) {
+	registration := models.CallbackRegistration{
+		UUID:        uuid.New(),
+		ServiceName: req.ServiceName,
+		URL:         req.URL,
+		Secret:      req.Secret,
+		Events:      req.Events,
+		IsActive:    true,
+	}
+
+	err := c.db.WithContext(ctx).
+		Create(&registration).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return &registration, nil
+}
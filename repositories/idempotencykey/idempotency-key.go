@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	errorWrap "payment-service/common/error"
+	errConstants "payment-service/constants/error"
+	errPayment "payment-service/constants/error/payment"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+
+	"gorm.io/gorm"
+)
+
+type IdempotencyKeyRepository struct {
+	db *gorm.DB
+}
+
+type IIdempotencyKeyRepository interface {
+	FindByKey(context.Context, string, string) (*models.IdempotencyKey, error)
+	Create(context.Context, *gorm.DB, *dto.IdempotencyKeyRequest) error
+	SaveResponse(context.Context, *gorm.DB, string, string, string) error
+}
+
+func NewIdempotencyKeyRepository(db *gorm.DB) IIdempotencyKeyRepository {
+	return &IdempotencyKeyRepository{db: db}
+}
+
+func (i *IdempotencyKeyRepository) FindByKey(
+	ctx context.Context,
+	service string,
+	key string,
+) (*models.IdempotencyKey, error) {
+	var idempotencyKey models.IdempotencyKey
+
+	err := i.db.WithContext(ctx).
+		Where("service = ? AND key = ?", service, key).
+		First(&idempotencyKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return &idempotencyKey, nil
+}
+
+// Create claims the key. A concurrent request with the same key waits on the
+// unique index until the first transaction finishes and then gets
+// ErrIdempotencyKeyInProgress.
+func (i *IdempotencyKeyRepository) Create(ctx context.Context, tx *gorm.DB, req *dto.IdempotencyKeyRequest) error {
+	idempotencyKey := models.IdempotencyKey{
+		Service:     req.Service,
+		Key:         req.Key,
+		RequestHash: req.RequestHash,
+	}
+
+	err := tx.WithContext(ctx).
+		Create(&idempotencyKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errPayment.ErrIdempotencyKeyInProgress
+		}
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
+
+func (i *IdempotencyKeyRepository) SaveResponse(
+	ctx context.Context,
+	tx *gorm.DB,
+	service string,
+	key string,
+	response string,
+) error {
+	err := tx.WithContext(ctx).
+		Model(&models.IdempotencyKey{}).
+		Where("service = ? AND key = ?", service, key).
+		Update("response", response).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	errorWrap "payment-service/common/error"
+	errConstants "payment-service/constants/error"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxEventRepository struct {
+	db *gorm.DB
+}
+
+type IOutboxEventRepository interface {
+	FindDeliverableForUpdate(context.Context, *gorm.DB, time.Time, int) ([]models.OutboxEvent, error)
+	Create(context.Context, *gorm.DB, *dto.OutboxEventRequest) error
+	MarkDelivered(context.Context, *gorm.DB, uint, time.Time) error
+	MarkFailed(context.Context, *gorm.DB, uint, string, time.Time) error
+}
+
+func NewOutboxEventRepository(db *gorm.DB) IOutboxEventRepository {
+	return &OutboxEventRepository{db: db}
+}
+
+// FindDeliverableForUpdate locks the oldest undelivered event of each
+// aggregate that is due for an attempt. Later events of an aggregate wait
+// until the ones before them are delivered, which keeps them in order.
+func (o *OutboxEventRepository) FindDeliverableForUpdate(
+	ctx context.Context,
+	tx *gorm.DB,
+	now time.Time,
+	limit int,
+) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("delivered_at IS NULL").
+		Where("next_attempt_at <= ?", now).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM outbox_events previous
+			WHERE previous.aggregate_id = outbox_events.aggregate_id
+			AND previous.delivered_at IS NULL
+			AND previous.id < outbox_events.id
+		)`).
+		Order("id asc").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return events, nil
+}
+
+func (o *OutboxEventRepository) Create(ctx context.Context, tx *gorm.DB, req *dto.OutboxEventRequest) error {
+	event := models.OutboxEvent{
+		UUID:          uuid.New(),
+		AggregateID:   req.AggregateID,
+		Topic:         req.Topic,
+		EventName:     req.EventName,
+		SchemaVersion: req.SchemaVersion,
+		CorrelationID: req.CorrelationID,
+		Payload:       req.Payload,
+		NextAttemptAt: time.Now(),
+	}
+
+	err := tx.WithContext(ctx).
+		Create(&event).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
+
+func (o *OutboxEventRepository) MarkDelivered(ctx context.Context, tx *gorm.DB, id uint, deliveredAt time.Time) error {
+	err := tx.WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"delivered_at": deliveredAt,
+			"attempts":     gorm.Expr("attempts + 1"),
+		}).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
+
+func (o *OutboxEventRepository) MarkFailed(
+	ctx context.Context,
+	tx *gorm.DB,
+	id uint,
+	lastError string,
+	nextAttemptAt time.Time,
+) error {
+	err := tx.WithContext(ctx).
+		Model(&models.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+			"attempts":        gorm.Expr("attempts + 1"),
+		}).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
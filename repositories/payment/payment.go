@@ -10,9 +10,11 @@ import (
 	errPayment "payment-service/constants/error/payment"
 	"payment-service/domain/dto"
 	"payment-service/domain/models"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepository struct {
@@ -23,6 +25,11 @@ type IPaymentRepository interface {
 	FindAllWithPagination(context.Context, *dto.PaymentRequestParam) ([]models.Payment, int64, error)
 	FindByUUID(context.Context, string) (*models.Payment, error)
 	FindByOrderID(context.Context, string) (*models.Payment, error)
+	FindByOrderIDForUpdate(context.Context, *gorm.DB, string) (*models.Payment, error)
+	FindByUUIDForUpdate(context.Context, *gorm.DB, string) (*models.Payment, error)
+	FindOverdue(context.Context, time.Time, int) ([]models.Payment, error)
+	FindUnpaidUpdatedBefore(context.Context, time.Time, int) ([]models.Payment, error)
+	FindForReplay(context.Context, *dto.ReplayRequest) ([]models.Payment, error)
 	Create(context.Context, *gorm.DB, *dto.PaymentRequest) (*models.Payment, error)
 	Update(context.Context, *gorm.DB, string, *dto.UpdatePaymentRequest) (*models.Payment, error)
 }
@@ -73,6 +80,10 @@ func (p *PaymentRepository) FindByUUID(ctx context.Context, uuid string) (*model
 	var payment models.Payment
 
 	err := p.db.WithContext(ctx).
+		Preload("Customer").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id asc")
+		}).
 		Where("uuid = ?", uuid).
 		First(&payment).Error
 	if err != nil {
@@ -101,6 +112,125 @@ func (p *PaymentRepository) FindByOrderID(ctx context.Context, orderID string) (
 	return &payment, nil
 }
 
+func (p *PaymentRepository) FindByOrderIDForUpdate(
+	ctx context.Context,
+	tx *gorm.DB,
+	orderID string,
+) (*models.Payment, error) {
+	var payment models.Payment
+
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ?", orderID).
+		First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorWrap.WrapError(errPayment.ErrPaymentNotFound)
+		}
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return &payment, nil
+}
+
+func (p *PaymentRepository) FindByUUIDForUpdate(
+	ctx context.Context,
+	tx *gorm.DB,
+	uuid string,
+) (*models.Payment, error) {
+	var payment models.Payment
+
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uuid = ?", uuid).
+		First(&payment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorWrap.WrapError(errPayment.ErrPaymentNotFound)
+		}
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return &payment, nil
+}
+
+// FindOverdue returns a batch of unpaid payments that expired before the given
+// time. They are not locked, the caller checks each one at its gateway and
+// closes it under its own row lock.
+func (p *PaymentRepository) FindOverdue(
+	ctx context.Context,
+	before time.Time,
+	limit int,
+) ([]models.Payment, error) {
+	var payments []models.Payment
+
+	err := p.db.WithContext(ctx).
+		Where("status IN ?", []constants.PaymentStatus{constants.Initial, constants.Pending}).
+		Where("expired_at < ?", before).
+		Order("expired_at asc").
+		Limit(limit).
+		Find(&payments).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return payments, nil
+}
+
+func (p *PaymentRepository) FindUnpaidUpdatedBefore(
+	ctx context.Context,
+	before time.Time,
+	limit int,
+) ([]models.Payment, error) {
+	var payments []models.Payment
+
+	err := p.db.WithContext(ctx).
+		Where("status IN ?", []constants.PaymentStatus{constants.Initial, constants.Pending}).
+		Where("updated_at < ?", before).
+		Order("updated_at asc").
+		Limit(limit).
+		Find(&payments).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return payments, nil
+}
+
+// FindForReplay loads the payments matching the replay filter together with
+// the histories to replay, oldest first.
+func (p *PaymentRepository) FindForReplay(ctx context.Context, req *dto.ReplayRequest) ([]models.Payment, error) {
+	var payments []models.Payment
+
+	histories := func(db *gorm.DB) *gorm.DB {
+		if req.From != nil {
+			db = db.Where("created_at >= ?", *req.From)
+		}
+		if req.To != nil {
+			db = db.Where("created_at < ?", *req.To)
+		}
+
+		return db.Order("created_at asc, id asc")
+	}
+
+	query := p.db.WithContext(ctx).Preload("PaymentHistories", histories)
+	if len(req.OrderIDs) > 0 {
+		query = query.Where("order_id IN ?", req.OrderIDs)
+	}
+	if req.From != nil || req.To != nil {
+		query = query.Where("EXISTS (?)", histories(p.db.Model(&models.PaymentHistory{}).
+			Select("1").
+			Where("payment_histories.payment_id = payments.id")))
+	}
+
+	err := query.Order("id asc").Find(&payments).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return payments, nil
+}
+
 func (p *PaymentRepository) Create(
 	ctx context.Context,
 	tx *gorm.DB,
@@ -115,12 +245,16 @@ func (p *PaymentRepository) Create(
 		PaymentLink: req.PaymentLink,
 		ExpiredAt:   &req.ExpiredAt,
 		Status:      &status,
+		Gateway:     req.Gateway,
 		Description: req.Description,
 	}
 
 	err := tx.WithContext(ctx).
 		Create(&payment).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errorWrap.WrapError(errPayment.ErrPaymentAlreadyExists)
+		}
 		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
 	}
 
@@ -137,9 +271,19 @@ func (p *PaymentRepository) Update(
 		TransactionID: req.TransactionID,
 		InvoiceLink:   req.InvoiceLink,
 		PaidAt:        req.PaidAt,
+		PaymentType:   req.PaymentType,
 		VANumber:      req.VANumber,
+		BillerCode:    req.BillerCode,
 		Bank:          req.Bank,
+		MaskedCard:    req.MaskedCard,
+		CardType:      req.CardType,
+		Store:         req.Store,
+		Issuer:        req.Issuer,
 		Acquirer:      req.Acquirer,
+		QRString:      req.QRString,
+	}
+	if req.PaymentLink != nil {
+		payment.PaymentLink = *req.PaymentLink
 	}
 
 	err := tx.WithContext(ctx).
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	errorWrap "payment-service/common/error"
+	errConstants "payment-service/constants/error"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+
+	"gorm.io/gorm"
+)
+
+type PaymentCustomerRepository struct {
+	db *gorm.DB
+}
+
+type IPaymentCustomerRepository interface {
+	FindByPaymentID(context.Context, *gorm.DB, uint) (*models.PaymentCustomer, error)
+	Create(context.Context, *gorm.DB, *dto.PaymentCustomerRequest) error
+}
+
+func NewPaymentCustomerRepository(db *gorm.DB) IPaymentCustomerRepository {
+	return &PaymentCustomerRepository{db: db}
+}
+
+// FindByPaymentID returns nil when the payment was created without customer
+// details.
+func (pc *PaymentCustomerRepository) FindByPaymentID(
+	ctx context.Context,
+	tx *gorm.DB,
+	paymentID uint,
+) (*models.PaymentCustomer, error) {
+	var customers []models.PaymentCustomer
+
+	err := tx.WithContext(ctx).
+		Where("payment_id = ?", paymentID).
+		Limit(1).
+		Find(&customers).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	if len(customers) == 0 {
+		return nil, nil
+	}
+
+	return &customers[0], nil
+}
+
+func (pc *PaymentCustomerRepository) Create(
+	ctx context.Context,
+	tx *gorm.DB,
+	req *dto.PaymentCustomerRequest,
+) error {
+	customer := &models.PaymentCustomer{
+		PaymentID: req.PaymentID,
+		Name:      req.Name,
+		Email:     req.Email,
+		Phone:     req.Phone,
+	}
+
+	err := tx.WithContext(ctx).Create(customer).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
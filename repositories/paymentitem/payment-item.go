@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	errorWrap "payment-service/common/error"
+	errConstants "payment-service/constants/error"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+
+	"gorm.io/gorm"
+)
+
+type PaymentItemRepository struct {
+	db *gorm.DB
+}
+
+type IPaymentItemRepository interface {
+	FindByPaymentID(context.Context, *gorm.DB, uint) ([]models.PaymentItem, error)
+	Create(context.Context, *gorm.DB, []dto.PaymentItemRequest) error
+}
+
+func NewPaymentItemRepository(db *gorm.DB) IPaymentItemRepository {
+	return &PaymentItemRepository{db: db}
+}
+
+func (pi *PaymentItemRepository) FindByPaymentID(
+	ctx context.Context,
+	tx *gorm.DB,
+	paymentID uint,
+) ([]models.PaymentItem, error) {
+	var items []models.PaymentItem
+
+	err := tx.WithContext(ctx).
+		Where("payment_id = ?", paymentID).
+		Order("id asc").
+		Find(&items).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return items, nil
+}
+
+func (pi *PaymentItemRepository) Create(
+	ctx context.Context,
+	tx *gorm.DB,
+	req []dto.PaymentItemRequest,
+) error {
+	if len(req) == 0 {
+		return nil
+	}
+
+	items := make([]models.PaymentItem, 0, len(req))
+	for _, item := range req {
+		items = append(items, models.PaymentItem{
+			PaymentID: item.PaymentID,
+			ItemID:    item.ItemID,
+			Name:      item.Name,
+			Price:     item.Price,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	err := tx.WithContext(ctx).Create(&items).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	errorWrap "payment-service/common/error"
+	"payment-service/constants"
+	errConstants "payment-service/constants/error"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RefundRepository struct {
+	db *gorm.DB
+}
+
+type IRefundRepository interface {
+	SumAmountByPaymentID(context.Context, *gorm.DB, uint) (money.Money, error)
+	SumSucceededAmountByPaymentID(context.Context, *gorm.DB, uint) (money.Money, error)
+	FindByRefundKeys(context.Context, *gorm.DB, uint, []string) ([]models.Refund, error)
+	Create(context.Context, *gorm.DB, *dto.CreateRefundRequest) (*models.Refund, error)
+	UpdateStatus(context.Context, *gorm.DB, uint, constants.RefundStatus, *string) error
+}
+
+func NewRefundRepository(db *gorm.DB) IRefundRepository {
+	return &RefundRepository{db: db}
+}
+
+// SumAmountByPaymentID sums the refunds that are not failed, a pending refund
+// keeps its amount from being refunded twice.
+func (r *RefundRepository) SumAmountByPaymentID(
+	ctx context.Context,
+	tx *gorm.DB,
+	paymentID uint,
+) (money.Money, error) {
+	return r.sumAmount(ctx, tx.Where("status <> ?", constants.RefundFailed), paymentID)
+}
+
+func (r *RefundRepository) SumSucceededAmountByPaymentID(
+	ctx context.Context,
+	tx *gorm.DB,
+	paymentID uint,
+) (money.Money, error) {
+	return r.sumAmount(ctx, tx.Where("status = ?", constants.RefundSucceeded), paymentID)
+}
+
+func (r *RefundRepository) sumAmount(ctx context.Context, tx *gorm.DB, paymentID uint) (money.Money, error) {
+	var total money.Money
+
+	err := tx.WithContext(ctx).
+		Model(&models.Refund{}).
+		Where("payment_id = ?", paymentID).
+		Select("COALESCE(SUM(amount), 0) AS amount, COALESCE(MAX(currency), '') AS currency").
+		Scan(&total).Error
+	if err != nil {
+		return money.Money{}, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return total, nil
+}
+
+func (r *RefundRepository) FindByRefundKeys(
+	ctx context.Context,
+	tx *gorm.DB,
+	paymentID uint,
+	refundKeys []string,
+) ([]models.Refund, error) {
+	var refunds []models.Refund
+	if len(refundKeys) == 0 {
+		return refunds, nil
+	}
+
+	err := tx.WithContext(ctx).
+		Where("payment_id = ? AND refund_key IN ?", paymentID, refundKeys).
+		Find(&refunds).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return refunds, nil
+}
+
+func (r *RefundRepository) Create(
+	ctx context.Context,
+	tx *gorm.DB,
+	req *dto.CreateRefundRequest,
+) (*models.Refund, error) {
+	refund := models.Refund{
+		UUID:      uuid.New(),
+		PaymentID: req.PaymentID,
+		RefundKey: req.RefundKey,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+		Status:    req.Status,
+	}
+
+	err := tx.WithContext(ctx).
+		Create(&refund).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return &refund, nil
+}
+
+func (r *RefundRepository) UpdateStatus(
+	ctx context.Context,
+	tx *gorm.DB,
+	id uint,
+	status constants.RefundStatus,
+	errMessage *string,
+) error {
+	err := tx.WithContext(ctx).
+		Model(&models.Refund{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status": status,
+			"error":  errMessage,
+		}).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
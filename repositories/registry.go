@@ -1,8 +1,17 @@
 package repositories
 
 import (
+	callbackAttemptRepo "payment-service/repositories/callbackattempt"
+	callbackDeliveryRepo "payment-service/repositories/callbackdelivery"
+	callbackRegistrationRepo "payment-service/repositories/callbackregistration"
+	idempotencyKeyRepo "payment-service/repositories/idempotencykey"
+	outboxEventRepo "payment-service/repositories/outboxevent"
 	paymentRepo "payment-service/repositories/payment"
+	paymentCustomerRepo "payment-service/repositories/paymentcustomer"
 	paymentHistoryRepo "payment-service/repositories/paymenthistory"
+	paymentItemRepo "payment-service/repositories/paymentitem"
+	refundRepo "payment-service/repositories/refund"
+	webhookNotificationRepo "payment-service/repositories/webhooknotification"
 
 	"gorm.io/gorm"
 )
@@ -14,6 +23,15 @@ type Registry struct {
 type IRepositoryRegistry interface {
 	GetPayment() paymentRepo.IPaymentRepository
 	GetPaymentHistory() paymentHistoryRepo.IPaymentHistoryRepository
+	GetPaymentCustomer() paymentCustomerRepo.IPaymentCustomerRepository
+	GetPaymentItem() paymentItemRepo.IPaymentItemRepository
+	GetWebhookNotification() webhookNotificationRepo.IWebhookNotificationRepository
+	GetRefund() refundRepo.IRefundRepository
+	GetOutboxEvent() outboxEventRepo.IOutboxEventRepository
+	GetCallbackRegistration() callbackRegistrationRepo.ICallbackRegistrationRepository
+	GetCallbackDelivery() callbackDeliveryRepo.ICallbackDeliveryRepository
+	GetCallbackAttempt() callbackAttemptRepo.ICallbackAttemptRepository
+	GetIdempotencyKey() idempotencyKeyRepo.IIdempotencyKeyRepository
 	GetTx() *gorm.DB
 }
 
@@ -29,6 +47,42 @@ func (r *Registry) GetPaymentHistory() paymentHistoryRepo.IPaymentHistoryReposit
 	return paymentHistoryRepo.NewPaymentHistoryRepository(r.db)
 }
 
+func (r *Registry) GetPaymentCustomer() paymentCustomerRepo.IPaymentCustomerRepository {
+	return paymentCustomerRepo.NewPaymentCustomerRepository(r.db)
+}
+
+func (r *Registry) GetPaymentItem() paymentItemRepo.IPaymentItemRepository {
+	return paymentItemRepo.NewPaymentItemRepository(r.db)
+}
+
+func (r *Registry) GetWebhookNotification() webhookNotificationRepo.IWebhookNotificationRepository {
+	return webhookNotificationRepo.NewWebhookNotificationRepository(r.db)
+}
+
+func (r *Registry) GetRefund() refundRepo.IRefundRepository {
+	return refundRepo.NewRefundRepository(r.db)
+}
+
+func (r *Registry) GetOutboxEvent() outboxEventRepo.IOutboxEventRepository {
+	return outboxEventRepo.NewOutboxEventRepository(r.db)
+}
+
+func (r *Registry) GetCallbackRegistration() callbackRegistrationRepo.ICallbackRegistrationRepository {
+	return callbackRegistrationRepo.NewCallbackRegistrationRepository(r.db)
+}
+
+func (r *Registry) GetCallbackDelivery() callbackDeliveryRepo.ICallbackDeliveryRepository {
+	return callbackDeliveryRepo.NewCallbackDeliveryRepository(r.db)
+}
+
+func (r *Registry) GetCallbackAttempt() callbackAttemptRepo.ICallbackAttemptRepository {
+	return callbackAttemptRepo.NewCallbackAttemptRepository(r.db)
+}
+
+func (r *Registry) GetIdempotencyKey() idempotencyKeyRepo.IIdempotencyKeyRepository {
+	return idempotencyKeyRepo.NewIdempotencyKeyRepository(r.db)
+}
+
 func (r *Registry) GetTx() *gorm.DB {
 	return r.db
 }
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	errorWrap "payment-service/common/error"
+	"payment-service/constants"
+	errConstants "payment-service/constants/error"
+	errPayment "payment-service/constants/error/payment"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookNotificationRepository struct {
+	db *gorm.DB
+}
+
+type IWebhookNotificationRepository interface {
+	FindByDedupeKey(context.Context, string) (*models.WebhookNotification, error)
+	LockByID(context.Context, *gorm.DB, uint) (*models.WebhookNotification, error)
+	Create(context.Context, *gorm.DB, *dto.WebhookNotificationRequest) (*models.WebhookNotification, error)
+	Update(context.Context, *gorm.DB, uint, *dto.UpdateWebhookNotificationRequest) error
+}
+
+func NewWebhookNotificationRepository(db *gorm.DB) IWebhookNotificationRepository {
+	return &WebhookNotificationRepository{db: db}
+}
+
+func (w *WebhookNotificationRepository) FindByDedupeKey(
+	ctx context.Context,
+	dedupeKey string,
+) (*models.WebhookNotification, error) {
+	var notification models.WebhookNotification
+
+	err := w.db.WithContext(ctx).
+		Where("dedupe_key = ?", dedupeKey).
+		First(&notification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return &notification, nil
+}
+
+// LockByID re-reads the notification with a row lock so concurrent deliveries
+// of the same notification are processed one at a time.
+func (w *WebhookNotificationRepository) LockByID(
+	ctx context.Context,
+	tx *gorm.DB,
+	id uint,
+) (*models.WebhookNotification, error) {
+	var notification models.WebhookNotification
+
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&notification).Error
+	if err != nil {
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return &notification, nil
+}
+
+// Create returns ErrDuplicateWebhook when a concurrent delivery of the same
+// notification stored it first.
+func (w *WebhookNotificationRepository) Create(
+	ctx context.Context,
+	tx *gorm.DB,
+	req *dto.WebhookNotificationRequest,
+) (*models.WebhookNotification, error) {
+	notification := models.WebhookNotification{
+		DedupeKey:         req.DedupeKey,
+		Gateway:           req.Gateway,
+		OrderID:           req.OrderID,
+		TransactionID:     req.TransactionID,
+		TransactionStatus: req.TransactionStatus,
+		Payload:           req.Payload,
+		Status:            constants.WebhookReceived,
+	}
+
+	err := tx.WithContext(ctx).
+		Create(&notification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, errPayment.ErrDuplicateWebhook
+		}
+		return nil, errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return &notification, nil
+}
+
+func (w *WebhookNotificationRepository) Update(
+	ctx context.Context,
+	tx *gorm.DB,
+	id uint,
+	req *dto.UpdateWebhookNotificationRequest,
+) error {
+	err := tx.WithContext(ctx).
+		Model(&models.WebhookNotification{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       req.Status,
+			"error":        req.Error,
+			"processed_at": req.ProcessedAt,
+			"attempts":     gorm.Expr("attempts + 1"),
+		}).Error
+	if err != nil {
+		return errorWrap.WrapError(errConstants.ErrSqlQuery)
+	}
+
+	return nil
+}
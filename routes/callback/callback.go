@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"payment-service/clients"
+	"payment-service/constants"
+	controllers "payment-service/controllers/http"
+	"payment-service/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CallbackRoutes struct {
+	controller controllers.IControllerRegistry
+	client     clients.IClientRegistry
+	group      *gin.RouterGroup
+}
+
+type ICallbackRoutes interface {
+	Run()
+}
+
+func NewCallbackRoutes(
+	controller controllers.IControllerRegistry,
+	client clients.IClientRegistry,
+	group *gin.RouterGroup,
+) ICallbackRoutes {
+	return &CallbackRoutes{
+		controller: controller,
+		client:     client,
+		group:      group,
+	}
+}
+
+func (cb *CallbackRoutes) Run() {
+	group := cb.group.Group("/callback")
+	group.Use(middlewares.Authenticate())
+	group.Use(middlewares.CheckRole(
+		[]string{
+			constants.Admin,
+		}, cb.client))
+	group.GET("", cb.controller.GetCallback().GetAll)
+	group.POST("", cb.controller.GetCallback().Register)
+	group.GET("/deliveries", cb.controller.GetCallback().GetDeliveries)
+	group.GET("/deliveries/:uuid", cb.controller.GetCallback().GetDeliveryByUUID)
+	group.POST("/deliveries/:uuid/redeliver", cb.controller.GetCallback().Redeliver)
+}
@@ -34,6 +34,7 @@ func NewPaymentRoutes(
 func (p *PaymentRoutes) Run() {
 	group := p.group.Group("/payment")
 	group.POST("/webhook", p.controller.GetPayment().Webhook)
+	group.POST("/webhook/:gateway", p.controller.GetPayment().Webhook)
 	group.Use(middlewares.Authenticate())
 	group.GET("", middlewares.CheckRole(
 		[]string{
@@ -52,4 +53,19 @@ func (p *PaymentRoutes) Run() {
 			constants.Customer,
 		}, p.client),
 		p.controller.GetPayment().Create)
+	group.POST("/:uuid/refunds", middlewares.CheckRole(
+		[]string{
+			constants.Admin,
+		}, p.client),
+		p.controller.GetPayment().Refund)
+	group.POST("/:uuid/cancel", middlewares.CheckRole(
+		[]string{
+			constants.Admin,
+		}, p.client),
+		p.controller.GetPayment().Cancel)
+	group.POST("/:uuid/expire", middlewares.CheckRole(
+		[]string{
+			constants.Admin,
+		}, p.client),
+		p.controller.GetPayment().Expire)
 }
@@ -3,6 +3,7 @@ package routes
 import (
 	"payment-service/clients"
 	controllers "payment-service/controllers/http"
+	routesCallback "payment-service/routes/callback"
 	routes "payment-service/routes/payment"
 
 	"github.com/gin-gonic/gin"
@@ -32,9 +33,14 @@ func NewRouteRegistry(
 
 func (r *Registry) Serve() {
 	r.paymentRoute().Run()
+	r.callbackRoute().Run()
 }
 
 func (r *Registry) paymentRoute() routes.IPaymentRoutes {
 	return routes.NewPaymentRoutes(r.controller, r.client, r.group)
 
 }
+
+func (r *Registry) callbackRoute() routesCallback.ICallbackRoutes {
+	return routesCallback.NewCallbackRoutes(r.controller, r.client, r.group)
+}
@@ -0,0 +1,48 @@
+package schedulers
+
+import (
+	"context"
+	"payment-service/services"
+	"time"
+)
+
+type CallbackDeliveryJob struct {
+	services  services.IServiceRegistry
+	interval  time.Duration
+	batchSize int
+}
+
+func NewCallbackDeliveryJob(
+	services services.IServiceRegistry,
+	interval time.Duration,
+	batchSize int,
+) *CallbackDeliveryJob {
+	return &CallbackDeliveryJob{
+		services:  services,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+func (c *CallbackDeliveryJob) Name() string {
+	return "callback-delivery"
+}
+
+func (c *CallbackDeliveryJob) Interval() time.Duration {
+	return c.interval
+}
+
+func (c *CallbackDeliveryJob) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		count, err := c.services.GetCallback().Deliver(ctx, c.batchSize)
+		if err != nil {
+			return err
+		}
+
+		if count < c.batchSize {
+			break
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package schedulers
+
+import (
+	"context"
+	"payment-service/services"
+	"time"
+)
+
+type OutboxRelayJob struct {
+	services  services.IServiceRegistry
+	interval  time.Duration
+	batchSize int
+}
+
+func NewOutboxRelayJob(
+	services services.IServiceRegistry,
+	interval time.Duration,
+	batchSize int,
+) *OutboxRelayJob {
+	return &OutboxRelayJob{
+		services:  services,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+func (o *OutboxRelayJob) Name() string {
+	return "outbox-relay"
+}
+
+func (o *OutboxRelayJob) Interval() time.Duration {
+	return o.interval
+}
+
+func (o *OutboxRelayJob) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		count, err := o.services.GetOutbox().Relay(ctx, o.batchSize)
+		if err != nil {
+			return err
+		}
+
+		if count < o.batchSize {
+			break
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package schedulers
+
+import (
+	"context"
+	"payment-service/services"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type PaymentExpiryJob struct {
+	services  services.IServiceRegistry
+	interval  time.Duration
+	grace     time.Duration
+	batchSize int
+}
+
+func NewPaymentExpiryJob(
+	services services.IServiceRegistry,
+	interval time.Duration,
+	grace time.Duration,
+	batchSize int,
+) *PaymentExpiryJob {
+	return &PaymentExpiryJob{
+		services:  services,
+		interval:  interval,
+		grace:     grace,
+		batchSize: batchSize,
+	}
+}
+
+func (p *PaymentExpiryJob) Name() string {
+	return "payment-expiry"
+}
+
+func (p *PaymentExpiryJob) Interval() time.Duration {
+	return p.interval
+}
+
+// Run keeps expiring batches until a batch comes back short, so a backlog of
+// overdue payments is drained in a single tick. A payment that could not be
+// closed makes its batch short, so it is retried on the next tick rather
+// than in a loop.
+func (p *PaymentExpiryJob) Run(ctx context.Context) error {
+	total := 0
+	for ctx.Err() == nil {
+		count, err := p.services.GetPayment().ExpireOverdue(ctx, p.grace, p.batchSize)
+		if err != nil {
+			return err
+		}
+
+		total += count
+		if count < p.batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		logrus.Infof("closed %d overdue payments", total)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package schedulers
+
+import (
+	"context"
+	"payment-service/services"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type PaymentReconciliationJob struct {
+	services  services.IServiceRegistry
+	interval  time.Duration
+	olderThan time.Duration
+	batchSize int
+}
+
+func NewPaymentReconciliationJob(
+	services services.IServiceRegistry,
+	interval time.Duration,
+	olderThan time.Duration,
+	batchSize int,
+) *PaymentReconciliationJob {
+	return &PaymentReconciliationJob{
+		services:  services,
+		interval:  interval,
+		olderThan: olderThan,
+		batchSize: batchSize,
+	}
+}
+
+func (p *PaymentReconciliationJob) Name() string {
+	return "payment-reconciliation"
+}
+
+func (p *PaymentReconciliationJob) Interval() time.Duration {
+	return p.interval
+}
+
+func (p *PaymentReconciliationJob) Run(ctx context.Context) error {
+	report, err := p.services.GetPayment().Reconcile(ctx, p.olderThan, p.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range report.Items {
+		if item.Changed {
+			logrus.Infof("reconciled order %s: %s -> %s",
+				item.OrderID.String(), item.PreviousStatus, item.CurrentStatus)
+		}
+
+		if item.Error != nil {
+			logrus.Errorf("failed to reconcile order %s: %s", item.OrderID.String(), *item.Error)
+		}
+	}
+
+	logrus.Infof("reconciliation checked %d payments, %d changed, %d failed",
+		report.Checked, report.Changed, report.Failed)
+	return nil
+}
@@ -0,0 +1,130 @@
+package schedulers
+
+import (
+	"context"
+	"payment-service/config"
+	schedulerCallback "payment-service/schedulers/callback"
+	schedulerOutbox "payment-service/schedulers/outbox"
+	schedulerPayment "payment-service/schedulers/payment"
+	"payment-service/services"
+	"sync"
+	"time"
+)
+
+const (
+	defaultInterval         = time.Minute
+	defaultBatchSize        = 100
+	defaultReconcileAfter   = 15 * time.Minute
+	defaultExpiryGrace      = 5 * time.Minute
+	defaultOutboxInterval   = time.Second
+	defaultCallbackInterval = 5 * time.Second
+)
+
+type Registry struct {
+	services services.IServiceRegistry
+}
+
+type ISchedulerRegistry interface {
+	GetPaymentExpiry() IJob
+	GetPaymentReconciliation() IJob
+	GetOutboxRelay() IJob
+	GetCallbackDelivery() IJob
+	Jobs() []IJob
+	Start(context.Context)
+}
+
+func NewSchedulerRegistry(services services.IServiceRegistry) ISchedulerRegistry {
+	return &Registry{
+		services: services,
+	}
+}
+
+func (r *Registry) GetPaymentExpiry() IJob {
+	interval := time.Duration(config.Config.Scheduler.ExpiryIntervalInSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	grace := time.Duration(config.Config.Scheduler.ExpiryGraceInSeconds) * time.Second
+	if grace <= 0 {
+		grace = defaultExpiryGrace
+	}
+
+	batchSize := config.Config.Scheduler.ExpiryBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return schedulerPayment.NewPaymentExpiryJob(r.services, interval, grace, batchSize)
+}
+
+func (r *Registry) GetPaymentReconciliation() IJob {
+	interval := time.Duration(config.Config.Scheduler.ReconcileIntervalInSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	olderThan := time.Duration(config.Config.Scheduler.ReconcileAfterInMinutes) * time.Minute
+	if olderThan <= 0 {
+		olderThan = defaultReconcileAfter
+	}
+
+	batchSize := config.Config.Scheduler.ReconcileBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return schedulerPayment.NewPaymentReconciliationJob(r.services, interval, olderThan, batchSize)
+}
+
+func (r *Registry) GetOutboxRelay() IJob {
+	interval := time.Duration(config.Config.Scheduler.OutboxIntervalInSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultOutboxInterval
+	}
+
+	batchSize := config.Config.Scheduler.OutboxBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return schedulerOutbox.NewOutboxRelayJob(r.services, interval, batchSize)
+}
+
+func (r *Registry) GetCallbackDelivery() IJob {
+	interval := time.Duration(config.Config.Scheduler.CallbackIntervalInSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultCallbackInterval
+	}
+
+	batchSize := config.Config.Scheduler.CallbackBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	return schedulerCallback.NewCallbackDeliveryJob(r.services, interval, batchSize)
+}
+
+func (r *Registry) Jobs() []IJob {
+	return []IJob{
+		r.GetPaymentExpiry(),
+		r.GetPaymentReconciliation(),
+		r.GetOutboxRelay(),
+		r.GetCallbackDelivery(),
+	}
+}
+
+// Start runs every job in its own goroutine and blocks until the context is
+// cancelled and all jobs have returned.
+func (r *Registry) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range r.Jobs() {
+		wg.Add(1)
+		go func(job IJob) {
+			defer wg.Done()
+			RunJob(ctx, job)
+		}(job)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,39 @@
+package schedulers
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type IJob interface {
+	Name() string
+	Interval() time.Duration
+	Run(context.Context) error
+}
+
+// RunJob runs the job every interval until the context is cancelled.
+func RunJob(ctx context.Context, job IJob) {
+	ticker := time.NewTicker(job.Interval())
+	defer ticker.Stop()
+
+	logrus.Infof("scheduler %s started, running every %s", job.Name(), job.Interval())
+	for {
+		RunJobOnce(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			logrus.Infof("scheduler %s stopped", job.Name())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func RunJobOnce(ctx context.Context, job IJob) {
+	err := job.Run(ctx)
+	if err != nil {
+		logrus.Errorf("scheduler %s failed: %v", job.Name(), err)
+	}
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	clients "payment-service/clients/callback"
+	"payment-service/common/util"
+	configApp "payment-service/config"
+	"payment-service/constants"
+	errCallback "payment-service/constants/error/callback"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+	"payment-service/repositories"
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/events"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	baseBackoff        = 5 * time.Second
+	maxBackoff         = time.Hour
+	defaultMaxAttempts = 10
+	// deliveryLease is how long a claimed delivery is reserved per delivery in
+	// its batch, longer than the callback client timeout.
+	deliveryLease = 15 * time.Second
+)
+
+// callbackEvents are the event names a registration can subscribe to.
+var callbackEvents = map[string]bool{
+	events.PaymentCreated:        true,
+	events.PaymentPending:        true,
+	events.PaymentSettled:        true,
+	events.PaymentExpired:        true,
+	events.PaymentRefunded:       true,
+	events.PaymentCancelled:      true,
+	events.PaymentFailed:         true,
+	events.PaymentAmountMismatch: true,
+}
+
+type CallbackService struct {
+	repository repositories.IRepositoryRegistry
+	callback   clients.ICallbackClient
+}
+
+type ICallbackService interface {
+	GetAll(context.Context) ([]dto.CallbackRegistrationResponse, error)
+	Register(context.Context, *dto.CallbackRegistrationRequest) (*dto.CallbackRegistrationResponse, error)
+	GetDeliveries(context.Context, *dto.CallbackDeliveryRequestParam) (*util.PaginationResult, error)
+	GetDeliveryByUUID(context.Context, string) (*dto.CallbackDeliveryResponse, error)
+	Redeliver(context.Context, string) (*dto.CallbackDeliveryResponse, error)
+	Deliver(context.Context, int) (int, error)
+}
+
+func NewCallbackService(
+	repository repositories.IRepositoryRegistry,
+	callback clients.ICallbackClient,
+) ICallbackService {
+	return &CallbackService{
+		repository: repository,
+		callback:   callback,
+	}
+}
+
+func (c *CallbackService) toRegistrationResponse(registration *models.CallbackRegistration) dto.CallbackRegistrationResponse {
+	return dto.CallbackRegistrationResponse{
+		UUID:        registration.UUID,
+		ServiceName: registration.ServiceName,
+		URL:         registration.URL,
+		Events:      registration.Events,
+		IsActive:    registration.IsActive,
+		CreatedAt:   registration.CreatedAt,
+		UpdatedAt:   registration.UpdatedAt,
+	}
+}
+
+func (c *CallbackService) toDeliveryResponse(delivery *models.CallbackDelivery) dto.CallbackDeliveryResponse {
+	return dto.CallbackDeliveryResponse{
+		UUID:          delivery.UUID,
+		ServiceName:   delivery.Registration.ServiceName,
+		URL:           delivery.Registration.URL,
+		AggregateID:   delivery.AggregateID,
+		EventName:     delivery.EventName,
+		Payload:       delivery.Payload,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		ResponseCode:  delivery.ResponseCode,
+		LastError:     delivery.LastError,
+		NextAttemptAt: delivery.NextAttemptAt,
+		DeliveredAt:   delivery.DeliveredAt,
+		History:       c.toAttemptResponses(delivery.History),
+		CreatedAt:     delivery.CreatedAt,
+		UpdatedAt:     delivery.UpdatedAt,
+	}
+}
+
+func (c *CallbackService) toAttemptResponses(attempts []models.CallbackAttempt) []dto.CallbackAttemptResponse {
+	responses := make([]dto.CallbackAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		responses = append(responses, dto.CallbackAttemptResponse{
+			Attempt:      attempt.Attempt,
+			ResponseCode: attempt.ResponseCode,
+			Error:        attempt.Error,
+			DurationMS:   attempt.DurationMS,
+			AttemptedAt:  attempt.AttemptedAt,
+		})
+	}
+
+	return responses
+}
+
+func (c *CallbackService) GetAll(ctx context.Context) ([]dto.CallbackRegistrationResponse, error) {
+	registrations, err := c.repository.GetCallbackRegistration().FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]dto.CallbackRegistrationResponse, 0, len(registrations))
+	for _, registration := range registrations {
+		results = append(results, c.toRegistrationResponse(&registration))
+	}
+
+	return results, nil
+}
+
+func (c *CallbackService) Register(
+	ctx context.Context,
+	req *dto.CallbackRegistrationRequest,
+) (*dto.CallbackRegistrationResponse, error) {
+	for _, event := range req.Events {
+		if !callbackEvents[event] {
+			return nil, errCallback.ErrUnknownEvent
+		}
+	}
+
+	secret, err := util.EncryptSecret(configApp.Config.CallbackSecretKey, req.Secret)
+	if err != nil {
+		logrus.Errorf("failed to encrypt callback secret: %v", err)
+		return nil, err
+	}
+
+	encrypted := *req
+	encrypted.Secret = secret
+	registration, err := c.repository.GetCallbackRegistration().Create(ctx, &encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	response := c.toRegistrationResponse(registration)
+	return &response, nil
+}
+
+func (c *CallbackService) GetDeliveries(
+	ctx context.Context,
+	param *dto.CallbackDeliveryRequestParam,
+) (*util.PaginationResult, error) {
+	deliveries, total, err := c.repository.GetCallbackDelivery().FindAllWithPagination(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryResults := make([]dto.CallbackDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryResults = append(deliveryResults, c.toDeliveryResponse(&delivery))
+	}
+
+	pagination := &util.PaginationParam{
+		Page:  param.Page,
+		Limit: param.Limit,
+		Count: total,
+		Data:  deliveryResults,
+	}
+
+	response := util.GeneratePagination(*pagination)
+
+	return &response, nil
+}
+
+func (c *CallbackService) GetDeliveryByUUID(ctx context.Context, uuid string) (*dto.CallbackDeliveryResponse, error) {
+	delivery, err := c.repository.GetCallbackDelivery().FindByUUID(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	response := c.toDeliveryResponse(delivery)
+	return &response, nil
+}
+
+func (c *CallbackService) Redeliver(ctx context.Context, uuid string) (*dto.CallbackDeliveryResponse, error) {
+	delivery, err := c.repository.GetCallbackDelivery().Redeliver(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	response := c.toDeliveryResponse(delivery)
+	return &response, nil
+}
+
+func (c *CallbackService) maxAttempts() int {
+	if configApp.Config.Scheduler.CallbackMaxAttempts > 0 {
+		return configApp.Config.Scheduler.CallbackMaxAttempts
+	}
+
+	return defaultMaxAttempts
+}
+
+// Deliver claims one batch of due callbacks, sends them outside the claiming
+// transaction and returns how many deliveries it picked up. Every attempt is
+// recorded. Failed deliveries are retried with exponential backoff until the
+// attempts run out, after which they stay failed until redelivered.
+func (c *CallbackService) Deliver(ctx context.Context, limit int) (int, error) {
+	var (
+		txErr, err error
+		deliveries []models.CallbackDelivery
+	)
+
+	err = c.repository.GetTx().Transaction(func(tx *gorm.DB) error {
+		deliveries, txErr = c.repository.GetCallbackDelivery().FindDeliverableForUpdate(ctx, tx, time.Now(), limit)
+		if txErr != nil {
+			return txErr
+		}
+
+		ids := make([]uint, 0, len(deliveries))
+		for _, delivery := range deliveries {
+			ids = append(ids, delivery.ID)
+		}
+
+		lease := time.Duration(len(deliveries)) * deliveryLease
+		return c.repository.GetCallbackDelivery().Claim(ctx, tx, ids, time.Now().Add(lease))
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		err = c.deliver(ctx, &delivery)
+		if err != nil {
+			logrus.Errorf("failed to record callback delivery %s: %v", delivery.UUID.String(), err)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// deliver sends one claimed delivery and records the attempt and its result.
+func (c *CallbackService) deliver(ctx context.Context, delivery *models.CallbackDelivery) error {
+	var responseCode int
+
+	attemptedAt := time.Now()
+	secret, sendErr := util.DecryptSecret(configApp.Config.CallbackSecretKey, delivery.Registration.Secret)
+	if sendErr == nil {
+		responseCode, sendErr = c.callback.Send(ctx, delivery.Registration.URL, secret, []byte(delivery.Payload))
+	}
+	duration := time.Since(attemptedAt)
+
+	var (
+		code       *int
+		errMessage *string
+	)
+	if responseCode != 0 {
+		code = &responseCode
+	}
+
+	if sendErr != nil {
+		logrus.Errorf("failed to deliver callback %s to %s (attempt %d): %v",
+			delivery.UUID.String(), delivery.Registration.URL, delivery.Attempts+1, sendErr)
+		message := sendErr.Error()
+		errMessage = &message
+	}
+
+	return c.repository.GetTx().Transaction(func(tx *gorm.DB) error {
+		err := c.repository.GetCallbackAttempt().Create(ctx, tx, &dto.CallbackAttemptRequest{
+			DeliveryID:   delivery.ID,
+			Attempt:      delivery.Attempts + 1,
+			ResponseCode: code,
+			Error:        errMessage,
+			Duration:     duration,
+			AttemptedAt:  attemptedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		if sendErr == nil {
+			return c.repository.GetCallbackDelivery().MarkDelivered(ctx, tx, delivery.ID, responseCode, time.Now())
+		}
+
+		status := constants.CallbackPending
+		if delivery.Attempts+1 >= c.maxAttempts() {
+			status = constants.CallbackFailed
+		}
+
+		return c.repository.GetCallbackDelivery().MarkFailed(ctx, tx, delivery.ID, code, *errMessage, status,
+			time.Now().Add(util.ExponentialBackoff(delivery.Attempts, baseBackoff, maxBackoff)))
+	})
+}
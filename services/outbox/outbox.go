@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"payment-service/common/util"
+	"payment-service/controllers/kafka"
+	"payment-service/domain/models"
+	"payment-service/repositories"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	baseBackoff = time.Second
+	maxBackoff  = 5 * time.Minute
+)
+
+type OutboxService struct {
+	repository repositories.IRepositoryRegistry
+	kafka      kafka.IKafkaRegistry
+}
+
+type IOutboxService interface {
+	Relay(context.Context, int) (int, error)
+}
+
+func NewOutboxService(
+	repository repositories.IRepositoryRegistry,
+	kafka kafka.IKafkaRegistry,
+) IOutboxService {
+	return &OutboxService{
+		repository: repository,
+		kafka:      kafka,
+	}
+}
+
+func (o *OutboxService) backoff(attempts int) time.Duration {
+	return util.ExponentialBackoff(attempts, baseBackoff, maxBackoff)
+}
+
+func (o *OutboxService) producerMessage(event *models.OutboxEvent) (*kafka.ProducerMessage, error) {
+	correlationID := event.UUID.String()
+	if event.CorrelationID != nil {
+		correlationID = *event.CorrelationID
+	}
+
+	sentAt := time.Now()
+	if event.CreatedAt != nil {
+		sentAt = *event.CreatedAt
+	}
+
+	return o.kafka.GetEncoder(event.Topic).Encode(&kafka.EventMessage{
+		ID:            event.UUID.String(),
+		Topic:         event.Topic,
+		Key:           event.AggregateID,
+		Name:          event.EventName,
+		SchemaVersion: event.SchemaVersion,
+		CorrelationID: correlationID,
+		Time:          sentAt,
+		Payload:       []byte(event.Payload),
+	})
+}
+
+// Relay publishes one batch of pending outbox events and returns how many
+// events it picked up. Failed events are retried later with backoff.
+func (o *OutboxService) Relay(ctx context.Context, limit int) (int, error) {
+	var (
+		txErr, err error
+		events     []models.OutboxEvent
+	)
+
+	err = o.repository.GetTx().Transaction(func(tx *gorm.DB) error {
+		events, txErr = o.repository.GetOutboxEvent().FindDeliverableForUpdate(ctx, tx, time.Now(), limit)
+		if txErr != nil {
+			return txErr
+		}
+
+		for _, event := range events {
+			message, produceErr := o.producerMessage(&event)
+			if produceErr == nil {
+				produceErr = o.kafka.GetKafkaProducer().Produce(message)
+			}
+			if produceErr != nil {
+				logrus.Errorf("failed to relay outbox event %s (attempt %d): %v",
+					event.UUID.String(), event.Attempts+1, produceErr)
+				txErr = o.repository.GetOutboxEvent().MarkFailed(ctx, tx, event.ID, produceErr.Error(),
+					time.Now().Add(o.backoff(event.Attempts)))
+			} else {
+				txErr = o.repository.GetOutboxEvent().MarkDelivered(ctx, tx, event.ID, time.Now())
+			}
+
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
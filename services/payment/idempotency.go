@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"payment-service/common/util"
+	errPayment "payment-service/constants/error/payment"
+	"payment-service/domain/dto"
+)
+
+func (s *PaymentService) requestHash(req *dto.PaymentRequest) string {
+	requestJSON, _ := json.Marshal(req)
+	return util.GenerateSHA256(string(requestJSON))
+}
+
+// idempotentResponse returns the stored response when the calling service
+// already used the key for the same request. It returns nil when the key is unused, and an
+// error when it was used for a different request or is still in flight.
+func (s *PaymentService) idempotentResponse(
+	ctx context.Context,
+	service string,
+	key string,
+	requestHash string,
+) (*dto.PaymentResponse, error) {
+	idempotencyKey, err := s.repository.GetIdempotencyKey().FindByKey(ctx, service, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if idempotencyKey == nil {
+		return nil, nil
+	}
+
+	if idempotencyKey.RequestHash != requestHash {
+		return nil, errPayment.ErrIdempotencyKeyReused
+	}
+
+	if idempotencyKey.Response == nil {
+		return nil, errPayment.ErrIdempotencyKeyInProgress
+	}
+
+	var response dto.PaymentResponse
+	err = json.Unmarshal([]byte(*idempotencyKey.Response), &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
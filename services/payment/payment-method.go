@@ -0,0 +1,109 @@
+package services
+
+import (
+	"payment-service/constants"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+)
+
+type paymentMethodExtractor func(*dto.Webhook, *dto.PaymentMethodDetail)
+
+// paymentMethodExtractors fills the payment method columns from the fields
+// the gateway sends for each payment type.
+var paymentMethodExtractors = map[string]paymentMethodExtractor{
+	constants.BankTransfer: extractBankTransfer,
+	constants.Echannel:     extractEchannel,
+	constants.CreditCard:   extractCreditCard,
+	constants.Gopay:        extractEWallet,
+	constants.ShopeePay:    extractEWallet,
+	constants.Qris:         extractEWallet,
+	constants.EWallet:      extractEWallet,
+	constants.Cstore:       extractCstore,
+}
+
+func extractBankTransfer(req *dto.Webhook, detail *dto.PaymentMethodDetail) {
+	if len(req.VANumbers) > 0 {
+		detail.Bank = &req.VANumbers[0].Bank
+		detail.VANumber = &req.VANumbers[0].VaNumber
+		return
+	}
+
+	if req.PermataVANumber != "" {
+		bank := constants.BankPermata
+		detail.Bank = &bank
+		detail.VANumber = &req.PermataVANumber
+	}
+}
+
+func extractEchannel(req *dto.Webhook, detail *dto.PaymentMethodDetail) {
+	bank := constants.BankMandiri
+	detail.Bank = &bank
+	detail.VANumber = nilIfEmpty(req.BillKey)
+	detail.BillerCode = nilIfEmpty(req.BillerCode)
+}
+
+func extractCreditCard(req *dto.Webhook, detail *dto.PaymentMethodDetail) {
+	detail.Bank = nilIfEmpty(req.Bank)
+	detail.MaskedCard = nilIfEmpty(req.MaskedCard)
+	detail.CardType = nilIfEmpty(req.CardType)
+}
+
+func extractEWallet(req *dto.Webhook, detail *dto.PaymentMethodDetail) {
+	detail.Issuer = req.Issuer
+	if detail.Issuer == nil {
+		detail.Issuer = nilIfEmpty(req.PaymentType)
+	}
+}
+
+func extractCstore(req *dto.Webhook, detail *dto.PaymentMethodDetail) {
+	detail.Store = nilIfEmpty(req.Store)
+	detail.VANumber = nilIfEmpty(req.PaymentCode)
+}
+
+func nilIfEmpty(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return &value
+}
+
+func (s *PaymentService) extractPaymentMethod(req *dto.Webhook) *dto.PaymentMethodDetail {
+	detail := &dto.PaymentMethodDetail{
+		PaymentType: nilIfEmpty(req.PaymentType),
+		Acquirer:    req.Acquirer,
+	}
+
+	extractor, ok := paymentMethodExtractors[req.PaymentType]
+	if ok {
+		extractor(req, detail)
+	}
+
+	return detail
+}
+
+// invoicePaymentDetail maps the stored payment method onto the bank and
+// number fields the invoice template shows for each payment type.
+func (s *PaymentService) invoicePaymentDetail(payment *models.Payment) (string, string) {
+	var bankName, number *string
+	switch {
+	case payment.Store != nil:
+		bankName, number = payment.Store, payment.VANumber
+	case payment.MaskedCard != nil:
+		bankName, number = payment.Bank, payment.MaskedCard
+	case payment.Issuer != nil:
+		bankName = payment.Issuer
+	default:
+		bankName, number = payment.Bank, payment.VANumber
+	}
+
+	return valueOrEmpty(bankName), valueOrEmpty(number)
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
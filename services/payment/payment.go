@@ -6,48 +6,55 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"os"
-	clients "payment-service/clients/midtrans"
+	clients "payment-service/clients/gateway"
 	gcs "payment-service/common/gcs"
 	"payment-service/common/util"
 	configApp "payment-service/config"
 	"payment-service/constants"
 	errPayment "payment-service/constants/error/payment"
-	"payment-service/controllers/kafka"
 	"payment-service/domain/dto"
 	"payment-service/domain/models"
 	"payment-service/repositories"
 	"strings"
 	"time"
 
+	"github.com/FaisalABR/payment-service/pkg/events"
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type PaymentService struct {
 	repository repositories.IRepositoryRegistry
 	gcs        gcs.IGCSClient
-	kafka      kafka.IKafkaRegistry
-	midtrans   clients.IMidtransClient
+	gateways   clients.IGatewayRegistry
 }
 
 type IPaymentService interface {
 	GetAllWithPagination(context.Context, *dto.PaymentRequestParam) (*util.PaginationResult, error)
 	GetByUUID(context.Context, string) (*dto.PaymentResponse, error)
+	GetByOrderID(context.Context, string) (*dto.PaymentResponse, error)
 	Create(context.Context, *dto.PaymentRequest) (*dto.PaymentResponse, error)
-	Webhook(context.Context, *dto.Webhook) error
+	Webhook(context.Context, constants.PaymentGateway, []byte, http.Header) error
+	Refund(context.Context, string, *dto.RefundRequest) (*dto.RefundResponse, error)
+	Cancel(context.Context, string) (*dto.PaymentResponse, error)
+	Expire(context.Context, string) (*dto.PaymentResponse, error)
+	ExpireOverdue(context.Context, time.Duration, int) (int, error)
+	Reconcile(context.Context, time.Duration, int) (*dto.ReconciliationReport, error)
+	Replay(context.Context, *dto.ReplayRequest) ([]dto.KafkaMessage, error)
 }
 
 func NewPaymentService(
 	repository repositories.IRepositoryRegistry,
 	gcs gcs.IGCSClient,
-	kafka kafka.IKafkaRegistry,
-	midtrans clients.IMidtransClient,
+	gateways clients.IGatewayRegistry,
 ) IPaymentService {
 	return &PaymentService{
 		repository: repository,
 		gcs:        gcs,
-		kafka:      kafka,
-		midtrans:   midtrans,
+		gateways:   gateways,
 	}
 }
 
@@ -66,13 +73,22 @@ func (s *PaymentService) GetAllWithPagination(
 			UUID:          payment.UUID,
 			OrderID:       payment.OrderID,
 			Amount:        payment.Amount,
+			Currency:      payment.Amount.Currency,
 			Status:        payment.Status.GetStatusString(),
+			Gateway:       payment.Gateway,
 			PaymentLink:   payment.PaymentLink,
 			InvoiceLink:   payment.InvoiceLink,
 			TransactionID: payment.TransactionID,
+			PaymentType:   payment.PaymentType,
 			VANumber:      payment.VANumber,
+			BillerCode:    payment.BillerCode,
 			Bank:          payment.Bank,
+			MaskedCard:    payment.MaskedCard,
+			CardType:      payment.CardType,
+			Store:         payment.Store,
+			Issuer:        payment.Issuer,
 			Acquirer:      payment.Acquirer,
+			QRString:      payment.QRString,
 			Description:   payment.Description,
 			PaidAt:        payment.PaidAt,
 			CreatedAt:     payment.CreatedAt,
@@ -94,64 +110,168 @@ func (s *PaymentService) GetAllWithPagination(
 
 }
 
-func (s *PaymentService) GetByUUID(ctx context.Context, uuid string) (*dto.PaymentResponse, error) {
-	payment, err := s.repository.GetPayment().FindByUUID(ctx, uuid)
-	if err != nil {
-		return nil, err
-	}
-
-	response := dto.PaymentResponse{
+func (s *PaymentService) toPaymentResponse(payment *models.Payment) *dto.PaymentResponse {
+	return &dto.PaymentResponse{
 		UUID:          payment.UUID,
 		OrderID:       payment.OrderID,
 		Amount:        payment.Amount,
+		Currency:      payment.Amount.Currency,
 		Status:        payment.Status.GetStatusString(),
+		Gateway:       payment.Gateway,
 		PaymentLink:   payment.PaymentLink,
 		InvoiceLink:   payment.InvoiceLink,
 		TransactionID: payment.TransactionID,
+		PaymentType:   payment.PaymentType,
 		VANumber:      payment.VANumber,
+		BillerCode:    payment.BillerCode,
 		Bank:          payment.Bank,
+		MaskedCard:    payment.MaskedCard,
+		CardType:      payment.CardType,
+		Store:         payment.Store,
+		Issuer:        payment.Issuer,
 		Acquirer:      payment.Acquirer,
+		QRString:      payment.QRString,
 		Description:   payment.Description,
+		Customer:      s.toCustomerDetail(payment.Customer),
+		Items:         s.toItemDetails(payment.Items),
 		PaidAt:        payment.PaidAt,
 		CreatedAt:     payment.CreatedAt,
 		UpdatedAt:     payment.UpdatedAt,
 		ExpiredAt:     payment.ExpiredAt,
 	}
+}
 
-	return &response, nil
+func (s *PaymentService) toCustomerDetail(customer *models.PaymentCustomer) *dto.CustomerDetail {
+	if customer == nil {
+		return nil
+	}
+
+	return &dto.CustomerDetail{
+		Name:  customer.Name,
+		Email: customer.Email,
+		Phone: customer.Phone,
+	}
+}
+
+func (s *PaymentService) toItemDetails(items []models.PaymentItem) []dto.ItemDetail {
+	if len(items) == 0 {
+		return nil
+	}
+
+	itemDetails := make([]dto.ItemDetail, 0, len(items))
+	for _, item := range items {
+		itemDetails = append(itemDetails, dto.ItemDetail{
+			ID:       item.ItemID,
+			Amount:   item.Price,
+			Name:     item.Name,
+			Quantity: item.Quantity,
+		})
+	}
+
+	return itemDetails
+}
+
+func (s *PaymentService) GetByUUID(ctx context.Context, uuid string) (*dto.PaymentResponse, error) {
+	payment, err := s.repository.GetPayment().FindByUUID(ctx, uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toPaymentResponse(payment), nil
+}
+
+func (s *PaymentService) GetByOrderID(ctx context.Context, orderID string) (*dto.PaymentResponse, error) {
+	payment, err := s.repository.GetPayment().FindByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toPaymentResponse(payment), nil
 }
 
 func (s *PaymentService) Create(ctx context.Context, req *dto.PaymentRequest) (*dto.PaymentResponse, error) {
 	var (
-		txErr, err error
-		payment    *models.Payment
-		response   *dto.PaymentResponse
-		midtrans   *clients.MidtransData
+		txErr, err     error
+		payment        *models.Payment
+		response       *dto.PaymentResponse
+		gateway        clients.IPaymentGateway
+		gatewayPayment *clients.GatewayPayment
+		requestHash    string
 	)
 
+	if req.Gateway == "" {
+		req.Gateway = constants.GatewayMidtrans
+	}
+
+	gateway, err = s.gateways.Get(req.Gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.IdempotencyKey != "" {
+		requestHash = s.requestHash(req)
+		response, err = s.idempotentResponse(ctx, req.ServiceName, req.IdempotencyKey, requestHash)
+		if err != nil || response != nil {
+			return response, err
+		}
+	}
+
 	err = s.repository.GetTx().Transaction(func(tx *gorm.DB) error {
 		if !req.ExpiredAt.After(time.Now()) {
-			return errPayment.ErrPaymentNotFound
+			return errPayment.ErrExpiredAt
 		}
 
-		midtrans, txErr = s.midtrans.CreatePaymentLink(req)
-		if txErr != nil {
-			return txErr
+		if req.IdempotencyKey != "" {
+			txErr = s.repository.GetIdempotencyKey().Create(ctx, tx, &dto.IdempotencyKeyRequest{
+				Service:     req.ServiceName,
+				Key:         req.IdempotencyKey,
+				RequestHash: requestHash,
+			})
+			if txErr != nil {
+				return txErr
+			}
 		}
 
-		paymentRequest := &dto.PaymentRequest{
+		// The payment is inserted before the gateway is called, so a second
+		// request for the order fails on the unique order id instead of
+		// creating another gateway transaction.
+		payment, txErr = s.repository.GetPayment().Create(ctx, tx, &dto.PaymentRequest{
 			OrderID:     req.OrderID,
 			Amount:      req.Amount,
 			Description: req.Description,
 			ExpiredAt:   req.ExpiredAt,
-			PaymentLink: midtrans.RedirectURL,
+			Gateway:     req.Gateway,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		gatewayPayment, txErr = gateway.CreatePayment(req)
+		if txErr != nil {
+			return txErr
+		}
+
+		// A direct charge already has the details the customer pays with,
+		// store them now instead of waiting for the pending notification.
+		paymentDetail := &dto.UpdatePaymentRequest{}
+		if gatewayPayment.TransactionID != "" {
+			paymentDetail = s.chargeDetail(gatewayPayment)
 		}
+		paymentDetail.PaymentLink = &gatewayPayment.PaymentLink
 
-		payment, txErr = s.repository.GetPayment().Create(ctx, tx, paymentRequest)
+		_, txErr = s.repository.GetPayment().Update(ctx, tx, req.OrderID, paymentDetail)
 		if txErr != nil {
 			return txErr
 		}
 
+		payment.PaymentLink = gatewayPayment.PaymentLink
+		payment.TransactionID = paymentDetail.TransactionID
+		payment.PaymentType = paymentDetail.PaymentType
+		payment.Bank = paymentDetail.Bank
+		payment.VANumber = paymentDetail.VANumber
+		payment.BillerCode = paymentDetail.BillerCode
+		payment.QRString = paymentDetail.QRString
+
 		txErr = s.repository.GetPaymentHistory().Create(ctx, tx, &dto.PaymentHistoryRequest{
 			PaymentID: uint(payment.ID),
 			Status:    payment.Status.GetStatusString(),
@@ -160,25 +280,104 @@ func (s *PaymentService) Create(ctx context.Context, req *dto.PaymentRequest) (*
 			return txErr
 		}
 
+		if req.CustomerDetail != nil {
+			txErr = s.repository.GetPaymentCustomer().Create(ctx, tx, &dto.PaymentCustomerRequest{
+				PaymentID: uint(payment.ID),
+				Name:      req.CustomerDetail.Name,
+				Email:     req.CustomerDetail.Email,
+				Phone:     req.CustomerDetail.Phone,
+			})
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		lineItems := req.LineItems()
+		items := make([]dto.PaymentItemRequest, 0, len(lineItems))
+		for _, item := range lineItems {
+			items = append(items, dto.PaymentItemRequest{
+				PaymentID: uint(payment.ID),
+				ItemID:    item.ID,
+				Name:      item.Name,
+				Price:     item.Amount,
+				Quantity:  item.Quantity,
+			})
+		}
+
+		txErr = s.repository.GetPaymentItem().Create(ctx, tx, items)
+		if txErr != nil {
+			return txErr
+		}
+
+		txErr = s.enqueueEvent(ctx, tx, payment.Status.GetStatusString(), payment, nil)
+		if txErr != nil {
+			return txErr
+		}
+
+		response = &dto.PaymentResponse{
+			UUID:          payment.UUID,
+			OrderID:       payment.OrderID,
+			Amount:        payment.Amount,
+			Currency:      payment.Amount.Currency,
+			Status:        payment.Status.GetStatusString(),
+			Gateway:       payment.Gateway,
+			PaymentLink:   payment.PaymentLink,
+			TransactionID: payment.TransactionID,
+			PaymentType:   payment.PaymentType,
+			VANumber:      payment.VANumber,
+			BillerCode:    payment.BillerCode,
+			Bank:          payment.Bank,
+			QRString:      payment.QRString,
+			Description:   payment.Description,
+			Customer:      req.CustomerDetail,
+			Items:         lineItems,
+			ExpiredAt:     payment.ExpiredAt,
+		}
+
+		if req.IdempotencyKey != "" {
+			responseJSON, _ := json.Marshal(response)
+			txErr = s.repository.GetIdempotencyKey().SaveResponse(
+				ctx,
+				tx,
+				req.ServiceName,
+				req.IdempotencyKey,
+				string(responseJSON),
+			)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
 		return nil
 	})
 
-	if err != nil {
-		return nil, err
+	// A concurrent request with the same key has finished in the meantime,
+	// answer with what it stored.
+	if errors.Is(err, errPayment.ErrIdempotencyKeyInProgress) {
+		response, err = s.idempotentResponse(ctx, req.ServiceName, req.IdempotencyKey, requestHash)
+		if err == nil && response == nil {
+			err = errPayment.ErrIdempotencyKeyInProgress
+		}
 	}
 
-	response = &dto.PaymentResponse{
-		UUID:        payment.UUID,
-		OrderID:     payment.OrderID,
-		Amount:      payment.Amount,
-		Status:      payment.Status.GetStatusString(),
-		PaymentLink: payment.PaymentLink,
-		Description: payment.Description,
+	if err != nil {
+		return nil, err
 	}
 
 	return response, nil
 }
 
+func (s *PaymentService) chargeDetail(gatewayPayment *clients.GatewayPayment) *dto.UpdatePaymentRequest {
+	return &dto.UpdatePaymentRequest{
+		TransactionID: nilIfEmpty(gatewayPayment.TransactionID),
+		PaymentType:   nilIfEmpty(gatewayPayment.PaymentType),
+		Bank:          nilIfEmpty(gatewayPayment.Bank),
+		VANumber:      nilIfEmpty(gatewayPayment.VANumber),
+		BillerCode:    nilIfEmpty(gatewayPayment.BillerCode),
+		QRString:      nilIfEmpty(gatewayPayment.QRString),
+	}
+}
+
 // buat function convertToIndonesiaMonth
 func (p *PaymentService) convertToIndonesiaMonth(englishMonth string) string {
 	mapIndonesiaMonth := map[string]string{
@@ -226,6 +425,53 @@ func (p *PaymentService) generatePDF(req *dto.InvoiceRequest) ([]byte, error) {
 	return pdf, nil
 }
 
+// invoiceItems lists the stored line items, falling back to a single line
+// with the payment description for payments created without items.
+func (s *PaymentService) invoiceItems(
+	payment *models.Payment,
+	items []models.PaymentItem,
+) ([]dto.InvoiceItem, error) {
+	if len(items) == 0 {
+		return []dto.InvoiceItem{
+			{
+				Description: valueOrEmpty(payment.Description),
+				Quantity:    1,
+				UnitPrice:   util.FormatRupiah(&payment.Amount),
+				Price:       util.FormatRupiah(&payment.Amount),
+			},
+		}, nil
+	}
+
+	invoiceItems := make([]dto.InvoiceItem, 0, len(items))
+	for _, item := range items {
+		subtotal, err := item.Price.Mul(int64(item.Quantity))
+		if err != nil {
+			return nil, err
+		}
+
+		invoiceItems = append(invoiceItems, dto.InvoiceItem{
+			Description: item.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   util.FormatRupiah(&item.Price),
+			Price:       util.FormatRupiah(&subtotal),
+		})
+	}
+
+	return invoiceItems, nil
+}
+
+func (s *PaymentService) invoiceCustomer(customer *models.PaymentCustomer) *dto.InvoiceCustomer {
+	if customer == nil {
+		return nil
+	}
+
+	return &dto.InvoiceCustomer{
+		Name:  customer.Name,
+		Email: customer.Email,
+		Phone: customer.Phone,
+	}
+}
+
 // buat function uploadToGCS
 func (p *PaymentService) uploadToGCS(ctx context.Context, invoiceNumber string, pdf []byte) (string, error) {
 	invoiceNumberReplace := strings.ReplaceAll(invoiceNumber, "/", "-")
@@ -246,159 +492,1075 @@ func (p *PaymentService) randomNumber() int {
 }
 
 func (p *PaymentService) mapTransactionStatusToEvent(status constants.PaymentStatusString) string {
-	var paymentStatus string
+	var eventName string
 	switch status {
-	case constants.PendingString:
-		paymentStatus = strings.ToUpper(string(constants.PendingString))
+	case constants.InitialString:
+		eventName = events.PaymentCreated
+	case constants.PendingString, constants.AuthorizeString, constants.CaptureString:
+		eventName = events.PaymentPending
 	case constants.SettlementString:
-		paymentStatus = strings.ToUpper(string(constants.SettlementString))
+		eventName = events.PaymentSettled
 	case constants.ExpiredString:
-		paymentStatus = strings.ToUpper(string(constants.ExpiredString))
+		eventName = events.PaymentExpired
+	case constants.CancelString:
+		eventName = events.PaymentCancelled
+	case constants.DenyString, constants.FailureString:
+		eventName = events.PaymentFailed
+	case constants.RefundString, constants.PartialRefundString:
+		eventName = events.PaymentRefunded
+	case constants.AmountMismatchString:
+		eventName = events.PaymentAmountMismatch
 	}
 
-	return paymentStatus
+	return eventName
 }
 
-func (s *PaymentService) produceToKafka(
-	req *dto.Webhook,
+// buildKafkaMessage builds a payment event and validates it against the
+// published contract before it can reach the outbox.
+func (s *PaymentService) buildKafkaMessage(
+	eventName string,
+	status constants.PaymentStatusString,
+	payment *models.Payment,
+	paidAt *time.Time,
+) (*dto.KafkaMessage, error) {
+	kafkaMessage := events.NewEnvelope(eventName, &dto.KafkaData{
+		OrderID:     payment.OrderID,
+		PaymentID:   payment.UUID,
+		Status:      string(status),
+		Amount:      payment.Amount,
+		Currency:    payment.Amount.Currency,
+		PaymentLink: payment.PaymentLink,
+		PaymentType: valueOrEmpty(payment.PaymentType),
+		Bank:        valueOrEmpty(payment.Bank),
+		VANumber:    valueOrEmpty(payment.VANumber),
+		BillerCode:  valueOrEmpty(payment.BillerCode),
+		QRString:    valueOrEmpty(payment.QRString),
+		PaidAt:      paidAt,
+		ExpiredAt:   *payment.ExpiredAt,
+	}, time.Now())
+
+	err := kafkaMessage.Validate()
+	if err != nil {
+		logrus.Errorf("invalid %s event for order %s: %v", eventName, payment.OrderID.String(), err)
+		return nil, err
+	}
+
+	return kafkaMessage, nil
+}
+
+// enqueueEvent writes the payment event to the outbox inside the caller's
+// transaction; the outbox relay publishes it to Kafka after commit.
+func (s *PaymentService) enqueueEvent(
+	ctx context.Context,
+	tx *gorm.DB,
+	status constants.PaymentStatusString,
 	payment *models.Payment,
 	paidAt *time.Time,
 ) error {
-	event := dto.KafkaEvent{
-		Name: s.mapTransactionStatusToEvent(req.TransactionStatus),
+	kafkaMessage, err := s.buildKafkaMessage(s.mapTransactionStatusToEvent(status), status, payment, paidAt)
+	if err != nil {
+		return err
 	}
 
-	metadata := dto.KafkaMetadata{
-		Sender:    "payment-service",
-		SendingAt: time.Now().Format(time.RFC3339),
+	return s.saveEvent(ctx, tx, payment, kafkaMessage)
+}
+
+// enqueueRefundEvent is enqueueEvent for a refund, the event carries the
+// amount of the refund and the total refunded so far.
+func (s *PaymentService) enqueueRefundEvent(
+	ctx context.Context,
+	tx *gorm.DB,
+	status constants.PaymentStatusString,
+	payment *models.Payment,
+	refundAmount money.Money,
+) error {
+	kafkaMessage, err := s.buildKafkaMessage(s.mapTransactionStatusToEvent(status), status, payment, payment.PaidAt)
+	if err != nil {
+		return err
 	}
 
-	body := dto.KafkaBody{
-		Type: "JSON",
-		Data: &dto.KafkaData{
-			OrderID:   payment.OrderID,
-			PaymentID: payment.UUID,
-			Status:    string(req.TransactionStatus),
-			PaidAt:    paidAt,
-			ExpiredAt: *payment.ExpiredAt,
-		},
+	refunded, err := s.repository.GetRefund().SumSucceededAmountByPaymentID(ctx, tx, uint(payment.ID))
+	if err != nil {
+		return err
 	}
 
-	kafkaMessage := dto.KafkaMessage{
-		Event:    event,
-		Metadata: metadata,
-		Body:     body,
+	if refundAmount.Amount > 0 {
+		kafkaMessage.Body.Data.RefundAmount = &refundAmount
+	}
+	if refunded.Amount > 0 {
+		kafkaMessage.Body.Data.RefundedAmount = &refunded
 	}
 
-	topic := configApp.Config.Kafka.Topic
-	kafkaMessageJSON, _ := json.Marshal(kafkaMessage)
-	err := s.kafka.GetKafkaProducer().Produce(topic, kafkaMessageJSON)
+	return s.saveEvent(ctx, tx, payment, kafkaMessage)
+}
+
+func (s *PaymentService) saveEvent(
+	ctx context.Context,
+	tx *gorm.DB,
+	payment *models.Payment,
+	kafkaMessage *dto.KafkaMessage,
+) error {
+	err := s.saveOutboxEvent(ctx, tx, configApp.Config.Kafka.Topic, payment, kafkaMessage)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.saveCallbackDeliveries(ctx, tx, payment, kafkaMessage)
 }
 
-func (s *PaymentService) Webhook(ctx context.Context, req *dto.Webhook) error {
-	var (
-		txErr, err         error
-		paymentAfterUpdate *models.Payment
-		paidAt             *time.Time
-		invoiceLink        string
-		pdf                []byte
-	)
-
-	err = s.repository.GetTx().Transaction(func(tx *gorm.DB) error {
-		_, txErr = s.repository.GetPayment().FindByOrderID(ctx, req.OrderID.String())
-		if txErr != nil {
-			return txErr
-		}
+// saveCallbackDeliveries queues the event for every callback registration
+// subscribed to it, in the same transaction as the outbox event.
+func (s *PaymentService) saveCallbackDeliveries(
+	ctx context.Context,
+	tx *gorm.DB,
+	payment *models.Payment,
+	kafkaMessage *dto.KafkaMessage,
+) error {
+	registrations, err := s.repository.GetCallbackRegistration().FindActiveByEvent(ctx, tx, kafkaMessage.Event.Name)
+	if err != nil {
+		return err
+	}
 
-		if req.TransactionStatus == constants.SettlementString {
-			now := time.Now()
-			paidAt = &now
-		}
+	if len(registrations) == 0 {
+		return nil
+	}
 
-		status := req.TransactionStatus.GetStatus()
-		vaNumber := req.VANumbers[0].VaNumber
-		bank := req.VANumbers[0].Bank
-		_, txErr = s.repository.GetPayment().Update(ctx, tx, req.OrderID.String(), &dto.UpdatePaymentRequest{
-			TransactionID: &req.TransactionID,
-			Status:        &status,
-			VANumber:      &vaNumber,
-			Bank:          &bank,
-			Acquirer:      req.Acquirer,
+	kafkaMessageJSON, _ := json.Marshal(kafkaMessage)
+	for _, registration := range registrations {
+		err = s.repository.GetCallbackDelivery().Create(ctx, tx, &dto.CallbackDeliveryRequest{
+			RegistrationID: registration.ID,
+			AggregateID:    payment.OrderID.String(),
+			EventName:      kafkaMessage.Event.Name,
+			Payload:        string(kafkaMessageJSON),
 		})
-		if txErr != nil {
-			return txErr
+		if err != nil {
+			return err
 		}
+	}
 
-		paymentAfterUpdate, txErr = s.repository.GetPayment().FindByOrderID(ctx, req.OrderID.String())
-		if txErr != nil {
-			return txErr
-		}
+	return nil
+}
 
-		txErr = s.repository.GetPaymentHistory().Create(ctx, tx, &dto.PaymentHistoryRequest{
-			PaymentID: uint(paymentAfterUpdate.ID),
-			Status:    paymentAfterUpdate.Status.GetStatusString(),
-		})
+func (s *PaymentService) saveOutboxEvent(
+	ctx context.Context,
+	tx *gorm.DB,
+	topic string,
+	payment *models.Payment,
+	kafkaMessage *dto.KafkaMessage,
+) error {
+	kafkaMessageJSON, _ := json.Marshal(kafkaMessage)
+	var correlationID *string
+	requestID, ok := ctx.Value(constants.RequestID).(string)
+	if ok && requestID != "" {
+		correlationID = &requestID
+	}
 
-		if req.TransactionStatus == constants.SettlementString {
-			paidDay := paidAt.Format("02")
-			paidMonth := s.convertToIndonesiaMonth(paidAt.Format("January"))
-			paidYear := paidAt.Format("2006")
-			invoiceNumber := fmt.Sprintf("INV/%s/ORD/%d", time.Now().Format(time.DateOnly), s.randomNumber())
-			total := util.FormatRupiah(&paymentAfterUpdate.Amount)
-			invoiceRequest := &dto.InvoiceRequest{
-				InvoiceNumber: invoiceNumber,
-				Data: dto.InvoiceData{
-					PaymentDetail: dto.InvoicePaymentDetail{
-						BankName:      *paymentAfterUpdate.Bank,
-						PaymentMethod: req.PaymentType,
-						VANumber:      *paymentAfterUpdate.VANumber,
-						Date:          fmt.Sprintf("%s %s %s", paidDay, paidMonth, paidYear),
-						IsPaid:        true,
-					},
-					Items: []dto.InvoiceItem{
-						{
-							Description: *paymentAfterUpdate.Description,
-							Price:       total,
-						},
-					},
-					Total: total,
-				},
-			}
+	return s.repository.GetOutboxEvent().Create(ctx, tx, &dto.OutboxEventRequest{
+		AggregateID:   payment.OrderID.String(),
+		Topic:         topic,
+		EventName:     kafkaMessage.Event.Name,
+		SchemaVersion: kafkaMessage.Metadata.SchemaVersion,
+		CorrelationID: correlationID,
+		Payload:       string(kafkaMessageJSON),
+	})
+}
 
-			pdf, txErr = s.generatePDF(invoiceRequest)
-			if txErr != nil {
-				return txErr
-			}
+// validateTransition guards the payment state machine so that late or
+// out-of-order notifications can never move a payment backwards.
+func (s *PaymentService) validateTransition(payment *models.Payment, req *dto.Webhook) error {
+	status := req.TransactionStatus
+	if !status.IsValid() {
+		logrus.Warnf("rejected webhook for order %s: unknown status %s", payment.OrderID.String(), status)
+		return errPayment.ErrUnknownStatus
+	}
 
-			invoiceLink, txErr = s.uploadToGCS(ctx, invoiceNumber, pdf)
-			if txErr != nil {
-				return txErr
+	// A customer who switches payment method on the Snap page starts a new
+	// transaction, its pending notification carries the new payment details.
+	if *payment.Status == constants.Pending && status.GetStatus() == constants.Pending &&
+		(payment.TransactionID == nil || *payment.TransactionID != req.TransactionID) {
+		return nil
+	}
+
+	if !payment.Status.CanTransitionTo(status.GetStatus()) {
+		logrus.Warnf("rejected webhook for order %s: transition %s -> %s is not allowed",
+			payment.OrderID.String(), payment.Status.GetStatusString(), status)
+		return errPayment.ErrInvalidStatus
+	}
+
+	return nil
+}
+
+// isAmountMismatch compares the amount and currency Midtrans reports for a
+// payment in progress with what we asked the customer to pay.
+func (s *PaymentService) isAmountMismatch(payment *models.Payment, req *dto.Webhook) bool {
+	switch req.TransactionStatus {
+	case constants.PendingString,
+		constants.AuthorizeString,
+		constants.CaptureString,
+		constants.SettlementString:
+	default:
+		return false
+	}
+
+	currency := payment.Amount.Currency
+	if req.Currency != "" && req.Currency != currency {
+		logrus.Warnf("amount mismatch for order %s: currency %s", payment.OrderID.String(), req.Currency)
+		return true
+	}
+
+	grossAmount, err := money.Parse(req.GrossAmount, currency)
+	if err != nil || grossAmount.Amount != payment.Amount.Amount {
+		logrus.Warnf("amount mismatch for order %s: expected %s, got %s",
+			payment.OrderID.String(), payment.Amount, req.GrossAmount)
+		return true
+	}
+
+	if req.TransactionStatus == constants.SettlementString && len(req.PaymentAmount) > 0 {
+		paidAmount := money.New(0, currency)
+		for _, item := range req.PaymentAmount {
+			if item.Amount == nil {
+				continue
 			}
 
-			_, txErr = s.repository.GetPayment().Update(ctx, tx, req.OrderID.String(), &dto.UpdatePaymentRequest{
-				InvoiceLink: &invoiceLink,
-			})
-			if txErr != nil {
-				return txErr
+			amount, err := money.Parse(*item.Amount, currency)
+			if err != nil {
+				return true
 			}
 
+			paidAmount, err = paidAmount.Add(amount)
+			if err != nil {
+				return true
+			}
 		}
-		return nil
 
-	})
-	if err != nil {
-		return nil
+		if paidAmount.Amount != payment.Amount.Amount {
+			logrus.Warnf("amount mismatch for order %s: expected %s, paid %s",
+				payment.OrderID.String(), payment.Amount, paidAmount)
+			return true
+		}
 	}
 
-	err = s.produceToKafka(req, paymentAfterUpdate, paidAt)
-	if err != nil {
-		return err
+	return false
+}
+
+// webhookDedupeKey identifies a notification, so redeliveries are skipped but
+// a later notification with the same status, e.g. a second partial refund,
+// is not.
+func (s *PaymentService) webhookDedupeKey(req *dto.Webhook) string {
+	key := fmt.Sprintf("%s:%s:%s:%s", req.TransactionID, req.TransactionStatus, req.StatusCode, req.GrossAmount)
+	if req.RefundAmount != "" || len(req.Refunds) > 0 {
+		refunded, _ := s.notifiedRefundAmount(req, money.DefaultCurrency)
+		key = fmt.Sprintf("%s:%s", key, refunded.Decimal())
+	}
+
+	return key
+}
+
+// notifiedRefundAmount is the total the gateway reports as refunded.
+func (s *PaymentService) notifiedRefundAmount(req *dto.Webhook, currency string) (money.Money, error) {
+	if len(req.Refunds) == 0 {
+		if req.RefundAmount == "" {
+			return money.New(0, currency), nil
+		}
+
+		return money.Parse(req.RefundAmount, currency)
+	}
+
+	total := money.New(0, currency)
+	for _, refund := range req.Refunds {
+		amount, err := money.Parse(refund.RefundAmount, currency)
+		if err != nil {
+			return money.Money{}, err
+		}
+
+		total, err = total.Add(amount)
+		if err != nil {
+			return money.Money{}, err
+		}
+	}
+
+	return total, nil
+}
+
+// isNotificationApplied reports whether the payment already reflects a
+// notification for its current status. Refunds, cancels and expiries made
+// through our API are applied before the gateway confirms them, so their
+// notification is a no-op when it is for the same transaction and amount and
+// reports no more refunded than we recorded.
+func (s *PaymentService) isNotificationApplied(
+	ctx context.Context,
+	tx *gorm.DB,
+	payment *models.Payment,
+	req *dto.Webhook,
+) (bool, error) {
+	if payment.TransactionID != nil && *payment.TransactionID != req.TransactionID {
+		return false, nil
+	}
+
+	grossAmount, err := money.Parse(req.GrossAmount, payment.Amount.Currency)
+	if err != nil || grossAmount.Amount != payment.Amount.Amount {
+		return false, nil
+	}
+
+	status := req.TransactionStatus.GetStatus()
+	if status != constants.PartialRefund && status != constants.Refund {
+		return true, nil
+	}
+
+	notified, err := s.notifiedRefundAmount(req, payment.Amount.Currency)
+	if err != nil {
+		return false, nil
+	}
+
+	refunded, err := s.repository.GetRefund().SumAmountByPaymentID(ctx, tx, uint(payment.ID))
+	if err != nil {
+		return false, err
+	}
+
+	return notified.Amount <= refunded.Amount, nil
+}
+
+// recordGatewayRefund applies the refunds the gateway reports. Our own
+// refunds it lists are confirmed, even one we marked failed because the
+// gateway call errored after the refund went through, and the part of the
+// refunded total we have no refund for, i.e. a refund made at the gateway
+// rather than through our API, is stored. It returns the amount it applied.
+func (s *PaymentService) recordGatewayRefund(
+	ctx context.Context,
+	tx *gorm.DB,
+	payment *models.Payment,
+	req *dto.Webhook,
+) (money.Money, error) {
+	applied := money.New(0, payment.Amount.Currency)
+	notified, err := s.notifiedRefundAmount(req, payment.Amount.Currency)
+	if err != nil {
+		return applied, errPayment.ErrInvalidPayload
+	}
+
+	refundKeys := make([]string, 0, len(req.Refunds))
+	for _, refund := range req.Refunds {
+		if refund.RefundKey != "" {
+			refundKeys = append(refundKeys, refund.RefundKey)
+		}
+	}
+
+	known, err := s.repository.GetRefund().FindByRefundKeys(ctx, tx, uint(payment.ID), refundKeys)
+	if err != nil {
+		return applied, err
+	}
+
+	knownKeys := make(map[string]bool, len(known))
+	for _, refund := range known {
+		knownKeys[refund.RefundKey] = true
+		if refund.Status == constants.RefundSucceeded {
+			continue
+		}
+
+		err = s.repository.GetRefund().UpdateStatus(ctx, tx, refund.ID, constants.RefundSucceeded, nil)
+		if err != nil {
+			return applied, err
+		}
+
+		applied, err = applied.Add(refund.Amount)
+		if err != nil {
+			return applied, err
+		}
+	}
+
+	refunded, err := s.repository.GetRefund().SumAmountByPaymentID(ctx, tx, uint(payment.ID))
+	if err != nil {
+		return applied, err
+	}
+
+	amount, err := notified.Sub(refunded)
+	if err != nil {
+		return applied, errPayment.ErrInvalidPayload
+	}
+
+	if amount.Amount <= 0 {
+		return applied, nil
+	}
+
+	refundKey := fmt.Sprintf("%s-%s", req.TransactionID, notified.Decimal())
+	reason := "refunded at the gateway"
+	if len(req.Refunds) > 0 {
+		latest := req.Refunds[len(req.Refunds)-1]
+		if latest.RefundKey != "" && !knownKeys[latest.RefundKey] {
+			refundKey = latest.RefundKey
+		}
+		if latest.Reason != "" {
+			reason = latest.Reason
+		}
+	}
+
+	_, err = s.repository.GetRefund().Create(ctx, tx, &dto.CreateRefundRequest{
+		PaymentID: uint(payment.ID),
+		RefundKey: refundKey,
+		Amount:    money.New(amount.Amount, payment.Amount.Currency),
+		Reason:    reason,
+		Status:    constants.RefundSucceeded,
+	})
+	if err != nil {
+		return applied, err
+	}
+
+	return applied.Add(amount)
+}
+
+// saveWebhookNotification stores the notification in the inbox, or returns the
+// existing entry when the gateway redelivers the same notification.
+func (s *PaymentService) saveWebhookNotification(
+	ctx context.Context,
+	gateway constants.PaymentGateway,
+	req *dto.Webhook,
+) (*models.WebhookNotification, error) {
+	dedupeKey := s.webhookDedupeKey(req)
+	notification, err := s.repository.GetWebhookNotification().FindByDedupeKey(ctx, dedupeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if notification != nil {
+		return notification, nil
+	}
+
+	payload := string(req.RawPayload)
+	if payload == "" {
+		payloadJSON, _ := json.Marshal(req)
+		payload = string(payloadJSON)
+	}
+
+	notification, err = s.repository.GetWebhookNotification().Create(ctx, s.repository.GetTx(), &dto.WebhookNotificationRequest{
+		DedupeKey:         dedupeKey,
+		Gateway:           gateway,
+		OrderID:           req.OrderID,
+		TransactionID:     req.TransactionID,
+		TransactionStatus: req.TransactionStatus,
+		Payload:           payload,
+	})
+
+	// A concurrent delivery of the same notification stored it in the
+	// meantime, this one is a duplicate of it.
+	if errors.Is(err, errPayment.ErrDuplicateWebhook) {
+		return s.repository.GetWebhookNotification().FindByDedupeKey(ctx, dedupeKey)
+	}
+
+	return notification, err
+}
+
+// Webhook verifies and parses a notification with the gateway it was sent
+// to before applying it.
+func (s *PaymentService) Webhook(
+	ctx context.Context,
+	gatewayName constants.PaymentGateway,
+	payload []byte,
+	header http.Header,
+) error {
+	gateway, err := s.gateways.Get(gatewayName)
+	if err != nil {
+		return err
+	}
+
+	req, err := gateway.ParseNotification(payload, header)
+	if err != nil {
+		return err
+	}
+
+	return s.processNotification(ctx, gatewayName, req)
+}
+
+func (s *PaymentService) processNotification(
+	ctx context.Context,
+	gatewayName constants.PaymentGateway,
+	req *dto.Webhook,
+) error {
+	var (
+		txErr, err         error
+		notification       *models.WebhookNotification
+		paymentAfterUpdate *models.Payment
+		paidAt             *time.Time
+		invoiceLink        string
+		pdf                []byte
+		rejectErr          error
+	)
+
+	notification, err = s.saveWebhookNotification(ctx, gatewayName, req)
+	if err != nil {
+		return err
+	}
+
+	if notification.Status == constants.WebhookProcessed {
+		logrus.Infof("skipping duplicate webhook %s for order %s", notification.DedupeKey, req.OrderID.String())
+		return nil
+	}
+
+	err = s.repository.GetTx().Transaction(func(tx *gorm.DB) error {
+		var lockedNotification *models.WebhookNotification
+		lockedNotification, txErr = s.repository.GetWebhookNotification().LockByID(ctx, tx, notification.ID)
+		if txErr != nil {
+			return txErr
+		}
+
+		if lockedNotification.Status == constants.WebhookProcessed {
+			return nil
+		}
+
+		var payment *models.Payment
+		payment, txErr = s.repository.GetPayment().FindByOrderIDForUpdate(ctx, tx, req.OrderID.String())
+		if txErr != nil {
+			return txErr
+		}
+
+		if payment.Gateway != gatewayName {
+			logrus.Warnf("rejected webhook for order %s: payment belongs to %s, not %s",
+				payment.OrderID.String(), payment.Gateway, gatewayName)
+			rejectErr = errPayment.ErrGatewayMismatch
+			errMessage := rejectErr.Error()
+			return s.repository.GetWebhookNotification().Update(ctx, tx, notification.ID,
+				&dto.UpdateWebhookNotificationRequest{
+					Status: constants.WebhookRejected,
+					Error:  &errMessage,
+				})
+		}
+
+		if !req.TransactionStatus.IsValid() {
+			logrus.Warnf("rejected webhook for order %s: unknown status %s",
+				payment.OrderID.String(), req.TransactionStatus)
+			rejectErr = errPayment.ErrUnknownStatus
+			errMessage := rejectErr.Error()
+			return s.repository.GetWebhookNotification().Update(ctx, tx, notification.ID,
+				&dto.UpdateWebhookNotificationRequest{
+					Status: constants.WebhookRejected,
+					Error:  &errMessage,
+				})
+		}
+
+		if *payment.Status == req.TransactionStatus.GetStatus() {
+			var applied bool
+			applied, txErr = s.isNotificationApplied(ctx, tx, payment, req)
+			if txErr != nil {
+				return txErr
+			}
+
+			if applied {
+				now := time.Now()
+				return s.repository.GetWebhookNotification().Update(ctx, tx, notification.ID,
+					&dto.UpdateWebhookNotificationRequest{
+						Status:      constants.WebhookProcessed,
+						ProcessedAt: &now,
+					})
+			}
+		}
+
+		rejectErr = s.validateTransition(payment, req)
+		if rejectErr != nil {
+			errMessage := rejectErr.Error()
+			return s.repository.GetWebhookNotification().Update(ctx, tx, notification.ID,
+				&dto.UpdateWebhookNotificationRequest{
+					Status: constants.WebhookRejected,
+					Error:  &errMessage,
+				})
+		}
+
+		status := req.TransactionStatus.GetStatus()
+		if s.isAmountMismatch(payment, req) {
+			status = constants.AmountMismatch
+		} else if status == constants.Settlement {
+			now := time.Now()
+			paidAt = &now
+		}
+		var refundAmount money.Money
+		if status == constants.PartialRefund || status == constants.Refund {
+			refundAmount, txErr = s.recordGatewayRefund(ctx, tx, payment, req)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		paymentMethod := s.extractPaymentMethod(req)
+		_, txErr = s.repository.GetPayment().Update(ctx, tx, req.OrderID.String(), &dto.UpdatePaymentRequest{
+			TransactionID: &req.TransactionID,
+			Status:        &status,
+			PaidAt:        paidAt,
+			PaymentType:   paymentMethod.PaymentType,
+			VANumber:      paymentMethod.VANumber,
+			BillerCode:    paymentMethod.BillerCode,
+			Bank:          paymentMethod.Bank,
+			MaskedCard:    paymentMethod.MaskedCard,
+			CardType:      paymentMethod.CardType,
+			Store:         paymentMethod.Store,
+			Issuer:        paymentMethod.Issuer,
+			Acquirer:      paymentMethod.Acquirer,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		paymentAfterUpdate, txErr = s.repository.GetPayment().FindByOrderIDForUpdate(ctx, tx, req.OrderID.String())
+		if txErr != nil {
+			return txErr
+		}
+
+		txErr = s.repository.GetPaymentHistory().Create(ctx, tx, &dto.PaymentHistoryRequest{
+			PaymentID: uint(paymentAfterUpdate.ID),
+			Status:    paymentAfterUpdate.Status.GetStatusString(),
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		if status == constants.Settlement && paymentAfterUpdate.InvoiceLink == nil {
+			paidDay := paidAt.Format("02")
+			paidMonth := s.convertToIndonesiaMonth(paidAt.Format("January"))
+			paidYear := paidAt.Format("2006")
+			invoiceNumber := fmt.Sprintf("INV/%s/ORD/%d", time.Now().Format(time.DateOnly), s.randomNumber())
+			total := util.FormatRupiah(&paymentAfterUpdate.Amount)
+			bankName, vaNumber := s.invoicePaymentDetail(paymentAfterUpdate)
+			var (
+				customer     *models.PaymentCustomer
+				items        []models.PaymentItem
+				invoiceItems []dto.InvoiceItem
+			)
+			customer, txErr = s.repository.GetPaymentCustomer().FindByPaymentID(ctx, tx, uint(paymentAfterUpdate.ID))
+			if txErr != nil {
+				return txErr
+			}
+
+			items, txErr = s.repository.GetPaymentItem().FindByPaymentID(ctx, tx, uint(paymentAfterUpdate.ID))
+			if txErr != nil {
+				return txErr
+			}
+
+			invoiceItems, txErr = s.invoiceItems(paymentAfterUpdate, items)
+			if txErr != nil {
+				return txErr
+			}
+
+			invoiceRequest := &dto.InvoiceRequest{
+				InvoiceNumber: invoiceNumber,
+				Data: dto.InvoiceData{
+					Customer: s.invoiceCustomer(customer),
+					PaymentDetail: dto.InvoicePaymentDetail{
+						BankName:      bankName,
+						PaymentMethod: req.PaymentType,
+						VANumber:      vaNumber,
+						Date:          fmt.Sprintf("%s %s %s", paidDay, paidMonth, paidYear),
+						IsPaid:        true,
+					},
+					Items: invoiceItems,
+					Total: total,
+				},
+			}
+
+			pdf, txErr = s.generatePDF(invoiceRequest)
+			if txErr != nil {
+				return txErr
+			}
+
+			invoiceLink, txErr = s.uploadToGCS(ctx, invoiceNumber, pdf)
+			if txErr != nil {
+				return txErr
+			}
+
+			_, txErr = s.repository.GetPayment().Update(ctx, tx, req.OrderID.String(), &dto.UpdatePaymentRequest{
+				InvoiceLink: &invoiceLink,
+			})
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		if status == constants.PartialRefund || status == constants.Refund {
+			txErr = s.enqueueRefundEvent(ctx, tx, paymentAfterUpdate.Status.GetStatusString(), paymentAfterUpdate, refundAmount)
+		} else {
+			txErr = s.enqueueEvent(ctx, tx, paymentAfterUpdate.Status.GetStatusString(), paymentAfterUpdate, paidAt)
+		}
+		if txErr != nil {
+			return txErr
+		}
+
+		now := time.Now()
+		txErr = s.repository.GetWebhookNotification().Update(ctx, tx, notification.ID, &dto.UpdateWebhookNotificationRequest{
+			Status:      constants.WebhookProcessed,
+			ProcessedAt: &now,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		return nil
+	})
+	if err != nil {
+		errMessage := err.Error()
+		updateErr := s.repository.GetWebhookNotification().Update(ctx, s.repository.GetTx(), notification.ID,
+			&dto.UpdateWebhookNotificationRequest{
+				Status: constants.WebhookFailed,
+				Error:  &errMessage,
+			})
+		if updateErr != nil {
+			logrus.Errorf("failed to mark webhook %s as failed: %v", notification.DedupeKey, updateErr)
+		}
+		return err
 	}
 
 	return nil
 }
+
+// Refund stores the refund as pending before calling the gateway, so a
+// refund the gateway made is never lost to a failed commit, and only moves
+// the payment once the gateway has accepted it. The pending amount counts
+// against the refundable amount meanwhile.
+func (s *PaymentService) Refund(
+	ctx context.Context,
+	uuid string,
+	req *dto.RefundRequest,
+) (*dto.RefundResponse, error) {
+	var (
+		txErr, err   error
+		payment      *models.Payment
+		refund       *models.Refund
+		refundStatus constants.PaymentStatus
+	)
+
+	err = s.repository.GetTx().Transaction(func(tx *gorm.DB) error {
+		payment, txErr = s.repository.GetPayment().FindByUUIDForUpdate(ctx, tx, uuid)
+		if txErr != nil {
+			return txErr
+		}
+
+		if !payment.Status.CanTransitionTo(constants.PartialRefund) {
+			return errPayment.ErrInvalidStatus
+		}
+
+		var refunded money.Money
+		refunded, txErr = s.repository.GetRefund().SumAmountByPaymentID(ctx, tx, uint(payment.ID))
+		if txErr != nil {
+			return txErr
+		}
+
+		var refundable money.Money
+		refundable, txErr = payment.Amount.Sub(refunded)
+		if txErr != nil {
+			return txErr
+		}
+
+		amount := refundable
+		if req.Amount != nil {
+			amount = money.New(req.Amount.Amount, payment.Amount.Currency)
+		}
+
+		if amount.Amount <= 0 || amount.Amount > refundable.Amount {
+			return errPayment.ErrRefundAmount
+		}
+
+		refund, txErr = s.repository.GetRefund().Create(ctx, tx, &dto.CreateRefundRequest{
+			PaymentID: uint(payment.ID),
+			RefundKey: fmt.Sprintf("%s-%d", payment.OrderID.String(), time.Now().UnixNano()),
+			Amount:    amount,
+			Reason:    req.Reason,
+			Status:    constants.RefundPending,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gateway, err := s.gateways.Get(payment.Gateway)
+	if err == nil {
+		_, err = gateway.Refund(payment.OrderID.String(), refund.RefundKey, refund.Amount, req.Reason)
+	}
+	if err != nil {
+		logrus.Errorf("refund %s of order %s failed: %v", refund.RefundKey, payment.OrderID.String(), err)
+		errMessage := err.Error()
+		updateErr := s.repository.GetRefund().UpdateStatus(ctx, s.repository.GetTx(), refund.ID,
+			constants.RefundFailed, &errMessage)
+		if updateErr != nil {
+			logrus.Errorf("failed to mark refund %s as failed: %v", refund.RefundKey, updateErr)
+		}
+
+		return nil, err
+	}
+
+	err = s.repository.GetTx().Transaction(func(tx *gorm.DB) error {
+		payment, txErr = s.repository.GetPayment().FindByUUIDForUpdate(ctx, tx, uuid)
+		if txErr != nil {
+			return txErr
+		}
+
+		// The gateway notification may have confirmed the refund first.
+		var refunds []models.Refund
+		refunds, txErr = s.repository.GetRefund().FindByRefundKeys(ctx, tx, uint(payment.ID),
+			[]string{refund.RefundKey})
+		if txErr != nil {
+			return txErr
+		}
+
+		if len(refunds) == 1 && refunds[0].Status == constants.RefundSucceeded {
+			refundStatus = *payment.Status
+			return nil
+		}
+
+		txErr = s.repository.GetRefund().UpdateStatus(ctx, tx, refund.ID, constants.RefundSucceeded, nil)
+		if txErr != nil {
+			return txErr
+		}
+
+		var refunded money.Money
+		refunded, txErr = s.repository.GetRefund().SumSucceededAmountByPaymentID(ctx, tx, uint(payment.ID))
+		if txErr != nil {
+			return txErr
+		}
+
+		refundStatus = constants.PartialRefund
+		if refunded.Amount >= payment.Amount.Amount {
+			refundStatus = constants.Refund
+		}
+
+		_, txErr = s.repository.GetPayment().Update(ctx, tx, payment.OrderID.String(), &dto.UpdatePaymentRequest{
+			Status: &refundStatus,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		txErr = s.repository.GetPaymentHistory().Create(ctx, tx, &dto.PaymentHistoryRequest{
+			PaymentID: uint(payment.ID),
+			Status:    refundStatus.GetStatusString(),
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		payment.Status = &refundStatus
+		txErr = s.enqueueRefundEvent(ctx, tx, refundStatus.GetStatusString(), payment, refund.Amount)
+		if txErr != nil {
+			return txErr
+		}
+
+		return nil
+	})
+	if err != nil {
+		logrus.Errorf("refund %s of order %s was made at the gateway but not recorded: %v",
+			refund.RefundKey, payment.OrderID.String(), err)
+		return nil, err
+	}
+
+	return &dto.RefundResponse{
+		UUID:          refund.UUID,
+		PaymentID:     payment.UUID,
+		RefundKey:     refund.RefundKey,
+		Amount:        refund.Amount,
+		Currency:      refund.Amount.Currency,
+		Reason:        refund.Reason,
+		Status:        constants.RefundSucceeded,
+		PaymentStatus: refundStatus.GetStatusString(),
+		CreatedAt:     refund.CreatedAt,
+	}, nil
+}
+
+func (s *PaymentService) Cancel(ctx context.Context, uuid string) (*dto.PaymentResponse, error) {
+	return s.closePayment(ctx, uuid, constants.Cancel, clients.IPaymentGateway.Cancel)
+}
+
+func (s *PaymentService) Expire(ctx context.Context, uuid string) (*dto.PaymentResponse, error) {
+	return s.closePayment(ctx, uuid, constants.Expired, clients.IPaymentGateway.Expire)
+}
+
+// closePayment stops a payment at its gateway and moves it to a terminal
+// status.
+func (s *PaymentService) closePayment(
+	ctx context.Context,
+	uuid string,
+	status constants.PaymentStatus,
+	closeTransaction func(clients.IPaymentGateway, string) (*clients.GatewayTransaction, error),
+) (*dto.PaymentResponse, error) {
+	var (
+		txErr, err error
+		payment    *models.Payment
+	)
+
+	err = s.repository.GetTx().Transaction(func(tx *gorm.DB) error {
+		payment, txErr = s.repository.GetPayment().FindByUUIDForUpdate(ctx, tx, uuid)
+		if txErr != nil {
+			return txErr
+		}
+
+		if !payment.Status.CanTransitionTo(status) {
+			return errPayment.ErrInvalidStatus
+		}
+
+		var gateway clients.IPaymentGateway
+		gateway, txErr = s.gateways.Get(payment.Gateway)
+		if txErr != nil {
+			return txErr
+		}
+
+		_, txErr = closeTransaction(gateway, payment.OrderID.String())
+		if txErr != nil {
+			return txErr
+		}
+
+		_, txErr = s.repository.GetPayment().Update(ctx, tx, payment.OrderID.String(), &dto.UpdatePaymentRequest{
+			Status: &status,
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		txErr = s.repository.GetPaymentHistory().Create(ctx, tx, &dto.PaymentHistoryRequest{
+			PaymentID: uint(payment.ID),
+			Status:    status.GetStatusString(),
+		})
+		if txErr != nil {
+			return txErr
+		}
+
+		payment.Status = &status
+		txErr = s.enqueueEvent(ctx, tx, status.GetStatusString(), payment, nil)
+		if txErr != nil {
+			return txErr
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetByUUID(ctx, uuid)
+}
+
+// ExpireOverdue closes one batch of payments still unpaid grace after their
+// ExpiredAt and returns how many were closed. Each payment is checked at its
+// gateway first: a status the gateway reports, e.g. a settlement whose
+// notification was lost, is applied through the webhook flow, otherwise the
+// transaction is expired at the gateway before the payment is, so it can't
+// settle afterwards. A payment the gateway can't be asked about is left for
+// the next run.
+func (s *PaymentService) ExpireOverdue(ctx context.Context, grace time.Duration, limit int) (int, error) {
+	payments, err := s.repository.GetPayment().FindOverdue(ctx, time.Now().Add(-grace), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, payment := range payments {
+		err = s.expireOverduePayment(ctx, &payment)
+		if err != nil {
+			logrus.Errorf("failed to expire overdue payment for order %s: %v", payment.OrderID.String(), err)
+			continue
+		}
+
+		closed++
+	}
+
+	return closed, nil
+}
+
+func (s *PaymentService) expireOverduePayment(ctx context.Context, payment *models.Payment) error {
+	gateway, err := s.gateways.Get(payment.Gateway)
+	if err != nil {
+		return err
+	}
+
+	status, err := gateway.GetStatus(payment.OrderID.String())
+	if err != nil {
+		return err
+	}
+
+	if status != nil && status.TransactionStatus != constants.PendingString {
+		return s.processNotification(ctx, payment.Gateway, status)
+	}
+
+	_, err = s.closePayment(ctx, payment.UUID.String(), constants.Expired, clients.IPaymentGateway.Expire)
+	if errors.Is(err, errPayment.ErrInvalidStatus) {
+		// A notification moved the payment on in the meantime.
+		return nil
+	}
+
+	return err
+}
+
+// Reconcile polls the gateways for payments still unpaid after olderThan and
+// applies the reported status through the webhook flow, so lost
+// notifications are recovered with the same guards as live ones.
+func (s *PaymentService) Reconcile(
+	ctx context.Context,
+	olderThan time.Duration,
+	limit int,
+) (*dto.ReconciliationReport, error) {
+	report := &dto.ReconciliationReport{
+		StartedAt: time.Now(),
+		Items:     make([]dto.ReconciliationItem, 0),
+	}
+
+	payments, err := s.repository.GetPayment().FindUnpaidUpdatedBefore(ctx, time.Now().Add(-olderThan), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, payment := range payments {
+		item := s.reconcilePayment(ctx, &payment)
+		report.Checked++
+		if item.Changed {
+			report.Changed++
+		}
+
+		if item.Error != nil {
+			report.Failed++
+		}
+
+		report.Items = append(report.Items, item)
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func (s *PaymentService) reconcilePayment(ctx context.Context, payment *models.Payment) dto.ReconciliationItem {
+	item := dto.ReconciliationItem{
+		OrderID:        payment.OrderID,
+		PreviousStatus: payment.Status.GetStatusString(),
+		CurrentStatus:  payment.Status.GetStatusString(),
+	}
+
+	fail := func(err error) dto.ReconciliationItem {
+		errMessage := err.Error()
+		item.Error = &errMessage
+		return item
+	}
+
+	gateway, err := s.gateways.Get(payment.Gateway)
+	if err != nil {
+		return fail(err)
+	}
+
+	status, err := gateway.GetStatus(payment.OrderID.String())
+	if err != nil {
+		return fail(err)
+	}
+
+	if status == nil {
+		return item
+	}
+
+	item.GatewayStatus = status.TransactionStatus
+	err = s.processNotification(ctx, payment.Gateway, status)
+	if err != nil {
+		return fail(err)
+	}
+
+	paymentAfterUpdate, err := s.repository.GetPayment().FindByOrderID(ctx, payment.OrderID.String())
+	if err != nil {
+		return fail(err)
+	}
+
+	item.CurrentStatus = paymentAfterUpdate.Status.GetStatusString()
+	item.Changed = item.CurrentStatus != item.PreviousStatus
+	return item
+}
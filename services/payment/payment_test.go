@@ -0,0 +1,1082 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	clients "payment-service/clients/gateway"
+	"payment-service/constants"
+	errPayment "payment-service/constants/error/payment"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+	"payment-service/middlewares"
+	"payment-service/repositories"
+	callbackRegistrationRepo "payment-service/repositories/callbackregistration"
+	outboxEventRepo "payment-service/repositories/outboxevent"
+	paymentRepo "payment-service/repositories/payment"
+	paymentHistoryRepo "payment-service/repositories/paymenthistory"
+	refundRepo "payment-service/repositories/refund"
+	webhookNotificationRepo "payment-service/repositories/webhooknotification"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FaisalABR/payment-service/pkg/events"
+	"github.com/FaisalABR/payment-service/pkg/money"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// fakeRepositoryRegistry only implements the repositories the tests use,
+// calling any other one panics.
+type fakeRepositoryRegistry struct {
+	repositories.IRepositoryRegistry
+	db                   *gorm.DB
+	payments             paymentRepo.IPaymentRepository
+	paymentHistories     paymentHistoryRepo.IPaymentHistoryRepository
+	refunds              refundRepo.IRefundRepository
+	outboxEvents         *fakeOutboxEventRepository
+	webhookNotifications webhookNotificationRepo.IWebhookNotificationRepository
+}
+
+func (f *fakeRepositoryRegistry) GetTx() *gorm.DB {
+	return f.db
+}
+
+func (f *fakeRepositoryRegistry) GetPayment() paymentRepo.IPaymentRepository {
+	return f.payments
+}
+
+func (f *fakeRepositoryRegistry) GetPaymentHistory() paymentHistoryRepo.IPaymentHistoryRepository {
+	return f.paymentHistories
+}
+
+func (f *fakeRepositoryRegistry) GetRefund() refundRepo.IRefundRepository {
+	return f.refunds
+}
+
+func (f *fakeRepositoryRegistry) GetWebhookNotification() webhookNotificationRepo.IWebhookNotificationRepository {
+	return f.webhookNotifications
+}
+
+func (f *fakeRepositoryRegistry) GetOutboxEvent() outboxEventRepo.IOutboxEventRepository {
+	return f.outboxEvents
+}
+
+func (f *fakeRepositoryRegistry) GetCallbackRegistration() callbackRegistrationRepo.ICallbackRegistrationRepository {
+	return fakeCallbackRegistrationRepository{}
+}
+
+// fakeSQLConnector opens connections that only begin, commit and roll back
+// transactions, so the service's transactions run around the fake
+// repositories without a database.
+type fakeSQLConnector struct{}
+
+func (fakeSQLConnector) Connect(context.Context) (driver.Conn, error) {
+	return fakeSQLConn{}, nil
+}
+
+func (fakeSQLConnector) Driver() driver.Driver {
+	return nil
+}
+
+type fakeSQLConn struct{}
+
+func (fakeSQLConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("the fake database runs no queries")
+}
+
+func (fakeSQLConn) Close() error {
+	return nil
+}
+
+func (fakeSQLConn) Begin() (driver.Tx, error) {
+	return fakeSQLTx{}, nil
+}
+
+type fakeSQLTx struct{}
+
+func (fakeSQLTx) Commit() error {
+	return nil
+}
+
+func (fakeSQLTx) Rollback() error {
+	return nil
+}
+
+func newFakeDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fakeSQLConnector{})}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open fake database: %v", err)
+	}
+
+	return db
+}
+
+// fakePaymentRepository holds a single payment and hands out copies, like
+// rows read from the database.
+type fakePaymentRepository struct {
+	paymentRepo.IPaymentRepository
+	payment *models.Payment
+}
+
+func (f *fakePaymentRepository) find() (*models.Payment, error) {
+	payment := *f.payment
+	return &payment, nil
+}
+
+func (f *fakePaymentRepository) FindByOrderIDForUpdate(context.Context, *gorm.DB, string) (*models.Payment, error) {
+	return f.find()
+}
+
+func (f *fakePaymentRepository) FindByUUIDForUpdate(context.Context, *gorm.DB, string) (*models.Payment, error) {
+	return f.find()
+}
+
+func (f *fakePaymentRepository) Update(
+	_ context.Context,
+	_ *gorm.DB,
+	_ string,
+	req *dto.UpdatePaymentRequest,
+) (*models.Payment, error) {
+	if req.Status != nil {
+		status := *req.Status
+		f.payment.Status = &status
+	}
+
+	if req.TransactionID != nil {
+		f.payment.TransactionID = req.TransactionID
+	}
+
+	if req.PaidAt != nil {
+		f.payment.PaidAt = req.PaidAt
+	}
+
+	if req.VANumber != nil {
+		f.payment.VANumber = req.VANumber
+	}
+
+	return f.find()
+}
+
+type fakePaymentHistoryRepository struct {
+	paymentHistoryRepo.IPaymentHistoryRepository
+	statuses []constants.PaymentStatusString
+}
+
+func (f *fakePaymentHistoryRepository) Create(_ context.Context, _ *gorm.DB, req *dto.PaymentHistoryRequest) error {
+	f.statuses = append(f.statuses, req.Status)
+	return nil
+}
+
+// fakeRefundRepository sums the refunds like the database does: pending
+// refunds count against the refundable amount, failed ones don't.
+type fakeRefundRepository struct {
+	refundRepo.IRefundRepository
+	refunds []models.Refund
+}
+
+func (f *fakeRefundRepository) sum(include func(models.Refund) bool) (money.Money, error) {
+	var err error
+	total := money.New(0, money.DefaultCurrency)
+	for _, refund := range f.refunds {
+		if include(refund) {
+			total, err = total.Add(refund.Amount)
+			if err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+
+	return total, nil
+}
+
+func (f *fakeRefundRepository) SumAmountByPaymentID(context.Context, *gorm.DB, uint) (money.Money, error) {
+	return f.sum(func(refund models.Refund) bool {
+		return refund.Status != constants.RefundFailed
+	})
+}
+
+func (f *fakeRefundRepository) SumSucceededAmountByPaymentID(context.Context, *gorm.DB, uint) (money.Money, error) {
+	return f.sum(func(refund models.Refund) bool {
+		return refund.Status == constants.RefundSucceeded
+	})
+}
+
+func (f *fakeRefundRepository) FindByRefundKeys(
+	_ context.Context,
+	_ *gorm.DB,
+	_ uint,
+	keys []string,
+) ([]models.Refund, error) {
+	refunds := make([]models.Refund, 0, len(keys))
+	for _, refund := range f.refunds {
+		for _, key := range keys {
+			if refund.RefundKey == key {
+				refunds = append(refunds, refund)
+			}
+		}
+	}
+
+	return refunds, nil
+}
+
+func (f *fakeRefundRepository) Create(
+	_ context.Context,
+	_ *gorm.DB,
+	req *dto.CreateRefundRequest,
+) (*models.Refund, error) {
+	f.refunds = append(f.refunds, models.Refund{
+		ID:        uint(len(f.refunds) + 1),
+		UUID:      uuid.New(),
+		PaymentID: req.PaymentID,
+		RefundKey: req.RefundKey,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+		Status:    req.Status,
+	})
+
+	refund := f.refunds[len(f.refunds)-1]
+	return &refund, nil
+}
+
+func (f *fakeRefundRepository) UpdateStatus(
+	_ context.Context,
+	_ *gorm.DB,
+	id uint,
+	status constants.RefundStatus,
+	errMessage *string,
+) error {
+	f.refunds[id-1].Status = status
+	f.refunds[id-1].Error = errMessage
+	return nil
+}
+
+// fakeWebhookNotificationRepository stores the notifications by dedupe key.
+type fakeWebhookNotificationRepository struct {
+	webhookNotificationRepo.IWebhookNotificationRepository
+	notifications []models.WebhookNotification
+}
+
+func (f *fakeWebhookNotificationRepository) FindByDedupeKey(
+	_ context.Context,
+	dedupeKey string,
+) (*models.WebhookNotification, error) {
+	for _, notification := range f.notifications {
+		if notification.DedupeKey == dedupeKey {
+			return &notification, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fakeWebhookNotificationRepository) LockByID(
+	_ context.Context,
+	_ *gorm.DB,
+	id uint,
+) (*models.WebhookNotification, error) {
+	notification := f.notifications[id-1]
+	return &notification, nil
+}
+
+func (f *fakeWebhookNotificationRepository) Create(
+	_ context.Context,
+	_ *gorm.DB,
+	req *dto.WebhookNotificationRequest,
+) (*models.WebhookNotification, error) {
+	f.notifications = append(f.notifications, models.WebhookNotification{
+		ID:                uint(len(f.notifications) + 1),
+		DedupeKey:         req.DedupeKey,
+		Gateway:           req.Gateway,
+		OrderID:           req.OrderID,
+		TransactionID:     req.TransactionID,
+		TransactionStatus: req.TransactionStatus,
+		Payload:           req.Payload,
+		Status:            constants.WebhookReceived,
+	})
+
+	notification := f.notifications[len(f.notifications)-1]
+	return &notification, nil
+}
+
+func (f *fakeWebhookNotificationRepository) Update(
+	_ context.Context,
+	_ *gorm.DB,
+	id uint,
+	req *dto.UpdateWebhookNotificationRequest,
+) error {
+	f.notifications[id-1].Status = req.Status
+	f.notifications[id-1].Error = req.Error
+	return nil
+}
+
+type fakeOutboxEventRepository struct {
+	outboxEventRepo.IOutboxEventRepository
+	created []dto.OutboxEventRequest
+}
+
+func (f *fakeOutboxEventRepository) Create(_ context.Context, _ *gorm.DB, req *dto.OutboxEventRequest) error {
+	f.created = append(f.created, *req)
+	return nil
+}
+
+// fakeConcurrentWebhookNotificationRepository behaves as if a concurrent delivery of
+// the same notification stored it between FindByDedupeKey and Create.
+type fakeConcurrentWebhookNotificationRepository struct {
+	webhookNotificationRepo.IWebhookNotificationRepository
+	concurrent *models.WebhookNotification
+	stored     bool
+}
+
+func (f *fakeConcurrentWebhookNotificationRepository) FindByDedupeKey(
+	context.Context,
+	string,
+) (*models.WebhookNotification, error) {
+	if !f.stored {
+		return nil, nil
+	}
+
+	return f.concurrent, nil
+}
+
+func (f *fakeConcurrentWebhookNotificationRepository) Create(
+	context.Context,
+	*gorm.DB,
+	*dto.WebhookNotificationRequest,
+) (*models.WebhookNotification, error) {
+	f.stored = true
+	return nil, errPayment.ErrDuplicateWebhook
+}
+
+type fakeGatewayRegistry struct {
+	gateway clients.IPaymentGateway
+}
+
+func (f fakeGatewayRegistry) Get(constants.PaymentGateway) (clients.IPaymentGateway, error) {
+	return f.gateway, nil
+}
+
+// fakeGateway accepts every refund, onRefund runs before it does and can fail
+// it.
+type fakeGateway struct {
+	clients.IPaymentGateway
+	onRefund func(refundKey string, amount money.Money) error
+	refunds  []money.Money
+}
+
+func (f *fakeGateway) Refund(
+	_ string,
+	refundKey string,
+	amount money.Money,
+	_ string,
+) (*clients.GatewayRefund, error) {
+	if f.onRefund != nil {
+		err := f.onRefund(refundKey, amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.refunds = append(f.refunds, amount)
+	return &clients.GatewayRefund{RefundKey: refundKey, Amount: amount.Decimal()}, nil
+}
+
+type fakeCallbackRegistrationRepository struct {
+	callbackRegistrationRepo.ICallbackRegistrationRepository
+}
+
+func (fakeCallbackRegistrationRepository) FindActiveByEvent(
+	context.Context,
+	*gorm.DB,
+	string,
+) ([]models.CallbackRegistration, error) {
+	return nil, nil
+}
+
+func TestEnqueueEventCarriesRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		requestID string
+	}{
+		{name: "request id header", requestID: "order-service-7f3a"},
+		{name: "generated request id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outboxEvents := &fakeOutboxEventRepository{}
+			service := &PaymentService{
+				repository: &fakeRepositoryRegistry{outboxEvents: outboxEvents},
+			}
+
+			status := constants.Pending
+			expiredAt := time.Now().Add(time.Hour)
+			payment := &models.Payment{
+				UUID:      uuid.New(),
+				OrderID:   uuid.New(),
+				Amount:    money.FromMajor(10000, money.DefaultCurrency),
+				Status:    &status,
+				ExpiredAt: &expiredAt,
+			}
+
+			router := gin.New()
+			router.Use(middlewares.RequestID())
+			router.POST("/payment", func(c *gin.Context) {
+				err := service.enqueueEvent(c, nil, constants.PendingString, payment, nil)
+				if err != nil {
+					t.Errorf("enqueueEvent() error = %v", err)
+				}
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/payment", nil)
+			if tt.requestID != "" {
+				request.Header.Set(constants.XRequestID, tt.requestID)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			want := recorder.Header().Get(constants.XRequestID)
+			if tt.requestID != "" && want != tt.requestID {
+				t.Fatalf("response %s = %q, want %q", constants.XRequestID, want, tt.requestID)
+			}
+
+			if len(outboxEvents.created) != 1 {
+				t.Fatalf("enqueueEvent() saved %d outbox events, want 1", len(outboxEvents.created))
+			}
+
+			correlationID := outboxEvents.created[0].CorrelationID
+			if correlationID == nil || *correlationID != want {
+				t.Errorf("outbox event correlation id = %v, want %q", correlationID, want)
+			}
+		})
+	}
+}
+
+func TestProcessNotificationConcurrentDuplicate(t *testing.T) {
+	notifications := &fakeConcurrentWebhookNotificationRepository{
+		concurrent: &models.WebhookNotification{
+			ID:        1,
+			DedupeKey: "settlement",
+			Status:    constants.WebhookProcessed,
+		},
+	}
+	service := &PaymentService{
+		repository: &fakeRepositoryRegistry{webhookNotifications: notifications},
+	}
+
+	err := service.processNotification(context.Background(), constants.GatewayMidtrans, &dto.Webhook{
+		OrderID:           uuid.New(),
+		TransactionID:     uuid.NewString(),
+		TransactionStatus: constants.SettlementString,
+		StatusCode:        "200",
+		GrossAmount:       "10000.00",
+	})
+	if err != nil {
+		t.Fatalf("processNotification() error = %v, want the duplicate skipped", err)
+	}
+
+	if !notifications.stored {
+		t.Errorf("processNotification() didn't try to store the notification")
+	}
+}
+
+// paymentTestEnv is a PaymentService over fake repositories holding one
+// payment of Rp 10.000.
+type paymentTestEnv struct {
+	service              *PaymentService
+	payments             *fakePaymentRepository
+	paymentHistories     *fakePaymentHistoryRepository
+	refunds              *fakeRefundRepository
+	outboxEvents         *fakeOutboxEventRepository
+	webhookNotifications *fakeWebhookNotificationRepository
+	gateway              *fakeGateway
+}
+
+func newPaymentTestEnv(t *testing.T, status constants.PaymentStatus) *paymentTestEnv {
+	t.Helper()
+
+	transactionID := "trx-1"
+	expiredAt := time.Now().Add(time.Hour)
+	payment := &models.Payment{
+		ID:            1,
+		UUID:          uuid.New(),
+		OrderID:       uuid.New(),
+		Amount:        money.FromMajor(10000, money.DefaultCurrency),
+		Status:        &status,
+		Gateway:       constants.GatewayMidtrans,
+		TransactionID: &transactionID,
+		ExpiredAt:     &expiredAt,
+	}
+	if status == constants.Settlement || status == constants.PartialRefund {
+		paidAt := time.Now()
+		payment.PaidAt = &paidAt
+	}
+
+	env := &paymentTestEnv{
+		payments:             &fakePaymentRepository{payment: payment},
+		paymentHistories:     &fakePaymentHistoryRepository{},
+		refunds:              &fakeRefundRepository{},
+		outboxEvents:         &fakeOutboxEventRepository{},
+		webhookNotifications: &fakeWebhookNotificationRepository{},
+		gateway:              &fakeGateway{},
+	}
+	env.service = &PaymentService{
+		repository: &fakeRepositoryRegistry{
+			db:                   newFakeDB(t),
+			payments:             env.payments,
+			paymentHistories:     env.paymentHistories,
+			refunds:              env.refunds,
+			outboxEvents:         env.outboxEvents,
+			webhookNotifications: env.webhookNotifications,
+		},
+		gateways: fakeGatewayRegistry{gateway: env.gateway},
+	}
+
+	return env
+}
+
+// notification is a bank transfer notification for the payment of the env.
+func (e *paymentTestEnv) notification(status constants.PaymentStatusString, grossAmount string) *dto.Webhook {
+	return &dto.Webhook{
+		OrderID:           e.payments.payment.OrderID,
+		TransactionID:     *e.payments.payment.TransactionID,
+		TransactionStatus: status,
+		StatusCode:        "200",
+		GrossAmount:       grossAmount,
+		Currency:          money.DefaultCurrency,
+		PaymentType:       constants.BankTransfer,
+	}
+}
+
+// refund adds a refund of the payment of the env.
+func (e *paymentTestEnv) refund(key string, major int64, status constants.RefundStatus) {
+	e.refunds.refunds = append(e.refunds.refunds, models.Refund{
+		ID:        uint(len(e.refunds.refunds) + 1),
+		UUID:      uuid.New(),
+		PaymentID: 1,
+		RefundKey: key,
+		Amount:    money.FromMajor(major, money.DefaultCurrency),
+		Reason:    "customer request",
+		Status:    status,
+	})
+}
+
+// refundNotification reports the refunds listed as major amounts by key.
+func (e *paymentTestEnv) refundNotification(refunds map[string]int64) *dto.Webhook {
+	var total int64
+	keys := make([]string, 0, len(refunds))
+	for key, amount := range refunds {
+		total += amount
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	status := constants.PartialRefundString
+	if total == 10000 {
+		status = constants.RefundString
+	}
+
+	req := e.notification(status, "10000.00")
+	req.RefundAmount = fmt.Sprintf("%d.00", total)
+	for _, key := range keys {
+		req.Refunds = append(req.Refunds, dto.WebhookRefund{
+			RefundKey:    key,
+			RefundAmount: fmt.Sprintf("%d.00", refunds[key]),
+		})
+	}
+
+	return req
+}
+
+// refundAmounts lists the refunds as "key status amount", with "api" as the
+// key of the refunds made through Refund.
+func (e *paymentTestEnv) refundAmounts() []string {
+	refunds := make([]string, 0, len(e.refunds.refunds))
+	for _, refund := range e.refunds.refunds {
+		key := refund.RefundKey
+		if strings.HasPrefix(key, e.payments.payment.OrderID.String()+"-") {
+			key = "api"
+		}
+		refunds = append(refunds, fmt.Sprintf("%s %s %s", key, refund.Status, refund.Amount.Decimal()))
+	}
+
+	return refunds
+}
+
+func (e *paymentTestEnv) eventNames() []string {
+	names := make([]string, 0, len(e.outboxEvents.created))
+	for _, event := range e.outboxEvents.created {
+		names = append(names, event.EventName)
+	}
+
+	return names
+}
+
+func TestProcessNotificationAmountMismatch(t *testing.T) {
+	paid := func(amount string) []dto.PaymentAmount {
+		return []dto.PaymentAmount{{Amount: &amount}}
+	}
+
+	tests := []struct {
+		name          string
+		status        constants.PaymentStatus
+		notification  func(*dto.Webhook)
+		wantStatus    constants.PaymentStatus
+		wantEventName string
+	}{
+		{
+			name:          "matching pending",
+			status:        constants.Initial,
+			notification:  func(req *dto.Webhook) {},
+			wantStatus:    constants.Pending,
+			wantEventName: events.PaymentPending,
+		},
+		{
+			name:   "pending with a lower gross amount",
+			status: constants.Initial,
+			notification: func(req *dto.Webhook) {
+				req.GrossAmount = "9000.00"
+			},
+			wantStatus:    constants.AmountMismatch,
+			wantEventName: events.PaymentAmountMismatch,
+		},
+		{
+			name:   "settlement with a lower gross amount",
+			status: constants.Pending,
+			notification: func(req *dto.Webhook) {
+				req.TransactionStatus = constants.SettlementString
+				req.GrossAmount = "9000.00"
+			},
+			wantStatus:    constants.AmountMismatch,
+			wantEventName: events.PaymentAmountMismatch,
+		},
+		{
+			name:   "settlement paid less than the gross amount",
+			status: constants.Pending,
+			notification: func(req *dto.Webhook) {
+				req.TransactionStatus = constants.SettlementString
+				req.PaymentAmount = paid("4000.00")
+			},
+			wantStatus:    constants.AmountMismatch,
+			wantEventName: events.PaymentAmountMismatch,
+		},
+		{
+			name:   "other currency",
+			status: constants.Initial,
+			notification: func(req *dto.Webhook) {
+				req.Currency = "USD"
+			},
+			wantStatus:    constants.AmountMismatch,
+			wantEventName: events.PaymentAmountMismatch,
+		},
+		{
+			name:   "unparsable gross amount",
+			status: constants.Initial,
+			notification: func(req *dto.Webhook) {
+				req.GrossAmount = "10.000,00"
+			},
+			wantStatus:    constants.AmountMismatch,
+			wantEventName: events.PaymentAmountMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newPaymentTestEnv(t, tt.status)
+			req := env.notification(constants.PendingString, "10000.00")
+			tt.notification(req)
+
+			err := env.service.processNotification(context.Background(), constants.GatewayMidtrans, req)
+			if err != nil {
+				t.Fatalf("processNotification() error = %v", err)
+			}
+
+			if got := *env.payments.payment.Status; got != tt.wantStatus {
+				t.Errorf("payment status = %s, want %s", got.GetStatusString(), tt.wantStatus.GetStatusString())
+			}
+
+			wantHistory := []constants.PaymentStatusString{tt.wantStatus.GetStatusString()}
+			if !reflect.DeepEqual(env.paymentHistories.statuses, wantHistory) {
+				t.Errorf("payment history = %v, want %v", env.paymentHistories.statuses, wantHistory)
+			}
+
+			if got := env.eventNames(); !reflect.DeepEqual(got, []string{tt.wantEventName}) {
+				t.Errorf("events = %v, want [%s]", got, tt.wantEventName)
+			}
+
+			if got := env.webhookNotifications.notifications[0].Status; got != constants.WebhookProcessed {
+				t.Errorf("notification status = %s, want %s", got, constants.WebhookProcessed)
+			}
+		})
+	}
+}
+
+// refundAmountsOfEvents lists the refundAmount of every outbox event.
+func (e *paymentTestEnv) refundAmountsOfEvents(t *testing.T) []string {
+	t.Helper()
+
+	var amounts []string
+	for _, event := range e.outboxEvents.created {
+		var kafkaMessage dto.KafkaMessage
+		err := json.Unmarshal([]byte(event.Payload), &kafkaMessage)
+		if err != nil {
+			t.Fatalf("unmarshal outbox payload: %v", err)
+		}
+
+		amount := "none"
+		if kafkaMessage.Body.Data.RefundAmount != nil {
+			amount = kafkaMessage.Body.Data.RefundAmount.Decimal()
+		}
+		amounts = append(amounts, amount)
+	}
+
+	return amounts
+}
+
+func TestProcessNotificationAppliesRefundsOnce(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      constants.PaymentStatus
+		refunds     func(*paymentTestEnv)
+		deliveries  []map[string]int64
+		wantRefunds []string
+		wantStatus  constants.PaymentStatus
+		wantHistory []constants.PaymentStatusString
+		wantEvents  []string
+	}{
+		{
+			name:   "refund made through the api",
+			status: constants.PartialRefund,
+			refunds: func(env *paymentTestEnv) {
+				env.refund("api-1", 4000, constants.RefundSucceeded)
+			},
+			deliveries:  []map[string]int64{{"api-1": 4000}},
+			wantRefunds: []string{"api-1 succeeded 4000"},
+			wantStatus:  constants.PartialRefund,
+		},
+		{
+			name:        "gateway refund delivered twice",
+			status:      constants.Settlement,
+			deliveries:  []map[string]int64{{"gw-1": 3000}, {"gw-1": 3000}},
+			wantRefunds: []string{"gw-1 succeeded 3000"},
+			wantStatus:  constants.PartialRefund,
+			wantHistory: []constants.PaymentStatusString{constants.PartialRefundString},
+			wantEvents:  []string{"3000"},
+		},
+		{
+			name:        "gateway refund listed again with a later one",
+			status:      constants.Settlement,
+			deliveries:  []map[string]int64{{"gw-1": 3000}, {"gw-1": 3000, "gw-2": 2000}},
+			wantRefunds: []string{"gw-1 succeeded 3000", "gw-2 succeeded 2000"},
+			wantStatus:  constants.PartialRefund,
+			wantHistory: []constants.PaymentStatusString{
+				constants.PartialRefundString,
+				constants.PartialRefundString,
+			},
+			wantEvents: []string{"3000", "2000"},
+		},
+		{
+			name:   "pending api refund confirmed by the gateway",
+			status: constants.Settlement,
+			refunds: func(env *paymentTestEnv) {
+				env.refund("api-1", 4000, constants.RefundPending)
+			},
+			deliveries:  []map[string]int64{{"api-1": 4000}, {"api-1": 4000}},
+			wantRefunds: []string{"api-1 succeeded 4000"},
+			wantStatus:  constants.PartialRefund,
+			wantHistory: []constants.PaymentStatusString{constants.PartialRefundString},
+			wantEvents:  []string{"4000"},
+		},
+		{
+			name:   "failed api refund the gateway made after all",
+			status: constants.Settlement,
+			refunds: func(env *paymentTestEnv) {
+				env.refund("api-1", 4000, constants.RefundFailed)
+			},
+			deliveries:  []map[string]int64{{"api-1": 4000}},
+			wantRefunds: []string{"api-1 succeeded 4000"},
+			wantStatus:  constants.PartialRefund,
+			wantHistory: []constants.PaymentStatusString{constants.PartialRefundString},
+			wantEvents:  []string{"4000"},
+		},
+		{
+			name:        "remaining amount refunded at the gateway",
+			status:      constants.Settlement,
+			deliveries:  []map[string]int64{{"gw-1": 6000}, {"gw-1": 6000, "gw-2": 4000}},
+			wantRefunds: []string{"gw-1 succeeded 6000", "gw-2 succeeded 4000"},
+			wantStatus:  constants.Refund,
+			wantHistory: []constants.PaymentStatusString{
+				constants.PartialRefundString,
+				constants.RefundString,
+			},
+			wantEvents: []string{"6000", "4000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newPaymentTestEnv(t, tt.status)
+			if tt.refunds != nil {
+				tt.refunds(env)
+			}
+
+			for _, refunds := range tt.deliveries {
+				err := env.service.processNotification(context.Background(), constants.GatewayMidtrans,
+					env.refundNotification(refunds))
+				if err != nil {
+					t.Fatalf("processNotification() error = %v", err)
+				}
+			}
+
+			if got := env.refundAmounts(); !reflect.DeepEqual(got, tt.wantRefunds) {
+				t.Errorf("refunds = %v, want %v", got, tt.wantRefunds)
+			}
+
+			if got := *env.payments.payment.Status; got != tt.wantStatus {
+				t.Errorf("payment status = %s, want %s", got.GetStatusString(), tt.wantStatus.GetStatusString())
+			}
+
+			if !reflect.DeepEqual(env.paymentHistories.statuses, tt.wantHistory) {
+				t.Errorf("payment history = %v, want %v", env.paymentHistories.statuses, tt.wantHistory)
+			}
+
+			if got := env.refundAmountsOfEvents(t); !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Errorf("refund events = %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestRefundGuardsRefundableAmount(t *testing.T) {
+	amount := func(major int64) *money.Money {
+		refundAmount := money.FromMajor(major, money.DefaultCurrency)
+		return &refundAmount
+	}
+
+	// Rp 4.000 refunded, Rp 5.000 failed and Rp 3.000 pending leave Rp 3.000
+	// of the Rp 10.000 payment refundable.
+	existing := []string{"done succeeded 4000", "lost failed 5000", "open pending 3000"}
+
+	tests := []struct {
+		name        string
+		status      constants.PaymentStatus
+		amount      *money.Money
+		gatewayErr  error
+		wantErr     error
+		wantRefunds []string
+		wantStatus  constants.PaymentStatus
+	}{
+		{
+			name:        "more than the remaining amount",
+			status:      constants.PartialRefund,
+			amount:      amount(3001),
+			wantErr:     errPayment.ErrRefundAmount,
+			wantRefunds: existing,
+			wantStatus:  constants.PartialRefund,
+		},
+		{
+			name:        "the remaining amount",
+			status:      constants.PartialRefund,
+			amount:      amount(3000),
+			wantRefunds: append(existing, "api succeeded 3000"),
+			wantStatus:  constants.PartialRefund,
+		},
+		{
+			name:        "no amount refunds the remaining amount",
+			status:      constants.PartialRefund,
+			wantRefunds: append(existing, "api succeeded 3000"),
+			wantStatus:  constants.PartialRefund,
+		},
+		{
+			name:        "gateway error",
+			status:      constants.PartialRefund,
+			amount:      amount(1000),
+			gatewayErr:  errPayment.ErrRefundFailed,
+			wantErr:     errPayment.ErrRefundFailed,
+			wantRefunds: append(existing, "api failed 1000"),
+			wantStatus:  constants.PartialRefund,
+		},
+		{
+			name:        "payment not settled",
+			status:      constants.Pending,
+			amount:      amount(1000),
+			wantErr:     errPayment.ErrInvalidStatus,
+			wantRefunds: existing,
+			wantStatus:  constants.Pending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newPaymentTestEnv(t, tt.status)
+			env.refund("done", 4000, constants.RefundSucceeded)
+			env.refund("lost", 5000, constants.RefundFailed)
+			env.refund("open", 3000, constants.RefundPending)
+			env.gateway.onRefund = func(string, money.Money) error {
+				return tt.gatewayErr
+			}
+
+			_, err := env.service.Refund(context.Background(), env.payments.payment.UUID.String(),
+				&dto.RefundRequest{Amount: tt.amount, Reason: "customer request"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Refund() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := env.refundAmounts(); !reflect.DeepEqual(got, tt.wantRefunds) {
+				t.Errorf("refunds = %v, want %v", got, tt.wantRefunds)
+			}
+
+			if got := *env.payments.payment.Status; got != tt.wantStatus {
+				t.Errorf("payment status = %s, want %s", got.GetStatusString(), tt.wantStatus.GetStatusString())
+			}
+		})
+	}
+}
+
+func TestRefundConcurrentRefunds(t *testing.T) {
+	refund := func(env *paymentTestEnv, major int64) error {
+		amount := money.FromMajor(major, money.DefaultCurrency)
+		_, err := env.service.Refund(context.Background(), env.payments.payment.UUID.String(),
+			&dto.RefundRequest{Amount: &amount, Reason: "customer request"})
+		return err
+	}
+
+	t.Run("pending refund counts against a concurrent one", func(t *testing.T) {
+		env := newPaymentTestEnv(t, constants.Settlement)
+		calls := 0
+		env.gateway.onRefund = func(string, money.Money) error {
+			calls++
+			if calls > 1 {
+				return nil
+			}
+
+			// The first refund of Rp 6.000 is pending at the gateway.
+			err := refund(env, 5000)
+			if !errors.Is(err, errPayment.ErrRefundAmount) {
+				t.Errorf("concurrent Refund() of 5000 error = %v, want %v", err, errPayment.ErrRefundAmount)
+			}
+
+			err = refund(env, 4000)
+			if err != nil {
+				t.Errorf("concurrent Refund() of 4000 error = %v", err)
+			}
+
+			return nil
+		}
+
+		err := refund(env, 6000)
+		if err != nil {
+			t.Fatalf("Refund() error = %v", err)
+		}
+
+		wantRefunds := []string{"api succeeded 6000", "api succeeded 4000"}
+		if got := env.refundAmounts(); !reflect.DeepEqual(got, wantRefunds) {
+			t.Errorf("refunds = %v, want %v", got, wantRefunds)
+		}
+
+		if got := *env.payments.payment.Status; got != constants.Refund {
+			t.Errorf("payment status = %s, want %s", got.GetStatusString(), constants.RefundString)
+		}
+
+		wantHistory := []constants.PaymentStatusString{constants.PartialRefundString, constants.RefundString}
+		if !reflect.DeepEqual(env.paymentHistories.statuses, wantHistory) {
+			t.Errorf("payment history = %v, want %v", env.paymentHistories.statuses, wantHistory)
+		}
+	})
+
+	t.Run("notification confirms the refund before the api records it", func(t *testing.T) {
+		env := newPaymentTestEnv(t, constants.Settlement)
+		env.gateway.onRefund = func(refundKey string, amount money.Money) error {
+			return env.service.processNotification(context.Background(), constants.GatewayMidtrans,
+				env.refundNotification(map[string]int64{refundKey: amount.Major()}))
+		}
+
+		err := refund(env, 4000)
+		if err != nil {
+			t.Fatalf("Refund() error = %v", err)
+		}
+
+		wantRefunds := []string{"api succeeded 4000"}
+		if got := env.refundAmounts(); !reflect.DeepEqual(got, wantRefunds) {
+			t.Errorf("refunds = %v, want %v", got, wantRefunds)
+		}
+
+		wantHistory := []constants.PaymentStatusString{constants.PartialRefundString}
+		if !reflect.DeepEqual(env.paymentHistories.statuses, wantHistory) {
+			t.Errorf("payment history = %v, want %v", env.paymentHistories.statuses, wantHistory)
+		}
+
+		if got := env.refundAmountsOfEvents(t); !reflect.DeepEqual(got, []string{"4000"}) {
+			t.Errorf("refund events = %v, want [4000]", got)
+		}
+	})
+}
+
+func TestProcessNotificationPendingTransaction(t *testing.T) {
+	tests := []struct {
+		name              string
+		transactionID     string
+		wantTransactionID string
+		wantVANumber      string
+		wantHistory       []constants.PaymentStatusString
+	}{
+		{
+			name:              "payment method switched on the snap page",
+			transactionID:     "trx-2",
+			wantTransactionID: "trx-2",
+			wantVANumber:      "8808123456",
+			wantHistory:       []constants.PaymentStatusString{constants.PendingString},
+		},
+		{
+			name:              "same transaction",
+			transactionID:     "trx-1",
+			wantTransactionID: "trx-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newPaymentTestEnv(t, constants.Pending)
+			req := env.notification(constants.PendingString, "10000.00")
+			req.TransactionID = tt.transactionID
+			req.VANumbers = []dto.VANumber{{Bank: constants.BankBNI, VaNumber: "8808123456"}}
+
+			err := env.service.processNotification(context.Background(), constants.GatewayMidtrans, req)
+			if err != nil {
+				t.Fatalf("processNotification() error = %v", err)
+			}
+
+			payment := env.payments.payment
+			if *payment.Status != constants.Pending || *payment.TransactionID != tt.wantTransactionID {
+				t.Errorf("payment = %s %s, want pending %s",
+					payment.Status.GetStatusString(), *payment.TransactionID, tt.wantTransactionID)
+			}
+
+			if got := valueOrEmpty(payment.VANumber); got != tt.wantVANumber {
+				t.Errorf("payment va number = %q, want %q", got, tt.wantVANumber)
+			}
+
+			if !reflect.DeepEqual(env.paymentHistories.statuses, tt.wantHistory) {
+				t.Errorf("payment history = %v, want %v", env.paymentHistories.statuses, tt.wantHistory)
+			}
+
+			if got := env.webhookNotifications.notifications[0].Status; got != constants.WebhookProcessed {
+				t.Errorf("notification status = %s, want %s", got, constants.WebhookProcessed)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	configApp "payment-service/config"
+	"payment-service/constants"
+	errPayment "payment-service/constants/error/payment"
+	"payment-service/domain/dto"
+	"payment-service/domain/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// replayPaidAt returns the paidAt the original event carried for a history
+// entry. Settlements recorded before PaidAt was stored fall back to the time
+// of the history entry so the rebuilt event still passes validation.
+func (s *PaymentService) replayPaidAt(payment *models.Payment, history *models.PaymentHistory) *time.Time {
+	switch history.Status {
+	case constants.SettlementString:
+		if payment.PaidAt != nil {
+			return payment.PaidAt
+		}
+		return history.CreatedAt
+	case constants.RefundString, constants.PartialRefundString:
+		return payment.PaidAt
+	default:
+		return nil
+	}
+}
+
+// Replay rebuilds the events of the matching payments from their histories.
+// Unless it is a dry run the events are written to the outbox for the
+// requested topic, so they are delivered in order with the live events.
+func (s *PaymentService) Replay(ctx context.Context, req *dto.ReplayRequest) ([]dto.KafkaMessage, error) {
+	if len(req.OrderIDs) == 0 && req.From == nil {
+		return nil, errPayment.ErrReplayFilter
+	}
+
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, errPayment.ErrReplayFilter
+	}
+
+	topic := req.Topic
+	if topic == "" {
+		topic = configApp.Config.Kafka.Topic
+	}
+
+	payments, err := s.repository.GetPayment().FindForReplay(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	type replayEvent struct {
+		payment *models.Payment
+		message *dto.KafkaMessage
+	}
+
+	replayEvents := make([]replayEvent, 0, len(payments))
+	kafkaMessages := make([]dto.KafkaMessage, 0, len(payments))
+	for i := range payments {
+		payment := &payments[i]
+		for j := range payment.PaymentHistories {
+			history := &payment.PaymentHistories[j]
+			kafkaMessage, err := s.buildKafkaMessage(
+				s.mapTransactionStatusToEvent(history.Status),
+				history.Status,
+				payment,
+				s.replayPaidAt(payment, history),
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			replayEvents = append(replayEvents, replayEvent{payment: payment, message: kafkaMessage})
+			kafkaMessages = append(kafkaMessages, *kafkaMessage)
+		}
+	}
+
+	if req.DryRun {
+		return kafkaMessages, nil
+	}
+
+	err = s.repository.GetTx().Transaction(func(tx *gorm.DB) error {
+		for _, event := range replayEvents {
+			txErr := s.saveOutboxEvent(ctx, tx, topic, event.payment, event.message)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("replayed %d events from %d payments to topic %s", len(kafkaMessages), len(payments), topic)
+	return kafkaMessages, nil
+}
@@ -1,10 +1,13 @@
 package services
 
 import (
-	clients "payment-service/clients/midtrans"
+	clientsCallback "payment-service/clients/callback"
+	clients "payment-service/clients/gateway"
 	gcs "payment-service/common/gcs"
 	"payment-service/controllers/kafka"
 	"payment-service/repositories"
+	servicesCallback "payment-service/services/callback"
+	servicesOutbox "payment-service/services/outbox"
 	services "payment-service/services/payment"
 )
 
@@ -12,27 +15,40 @@ type Registry struct {
 	repository repositories.IRepositoryRegistry
 	gcs        gcs.IGCSClient
 	kafka      kafka.IKafkaRegistry
-	midtrans   clients.IMidtransClient
+	gateways   clients.IGatewayRegistry
+	callback   clientsCallback.ICallbackClient
 }
 
 type IServiceRegistry interface {
 	GetPayment() services.IPaymentService
+	GetOutbox() servicesOutbox.IOutboxService
+	GetCallback() servicesCallback.ICallbackService
 }
 
 func NewServiceRegistry(
 	repository repositories.IRepositoryRegistry,
 	gcs gcs.IGCSClient,
 	kafka kafka.IKafkaRegistry,
-	midtrans clients.IMidtransClient,
+	gateways clients.IGatewayRegistry,
+	callback clientsCallback.ICallbackClient,
 ) IServiceRegistry {
 	return &Registry{
 		repository: repository,
 		gcs:        gcs,
 		kafka:      kafka,
-		midtrans:   midtrans,
+		gateways:   gateways,
+		callback:   callback,
 	}
 }
 
 func (r *Registry) GetPayment() services.IPaymentService {
-	return services.NewPaymentService(r.repository, r.gcs, r.kafka, r.midtrans)
+	return services.NewPaymentService(r.repository, r.gcs, r.gateways)
+}
+
+func (r *Registry) GetOutbox() servicesOutbox.IOutboxService {
+	return servicesOutbox.NewOutboxService(r.repository, r.kafka)
+}
+
+func (r *Registry) GetCallback() servicesCallback.ICallbackService {
+	return servicesCallback.NewCallbackService(r.repository, r.callback)
 }